@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// benchDocument builds an audit-dump shaped document of n records
+func benchDocument(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(&buf, `{"id":%d,"user":"user-%d","action":"update","ok":true,"meta":{"ip":"10.0.%d.%d","tags":["a","b","c"],"latency":%d.25}}`,
+			i, i, i%256, i%100, i%1000)
+	}
+	buf.WriteString("]")
+	return buf.Bytes()
+}
+
+// BenchmarkUnmarshalPrettyPrint is the original path: validate, unmarshal
+// into interface{}, then print
+func BenchmarkUnmarshalPrettyPrint(b *testing.B) {
+	data := benchDocument(10000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if !json.Valid(data) {
+			b.Fatal("invalid benchmark document")
+		}
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			b.Fatal(err)
+		}
+		if err := prettyPrint(io.Discard, v, "  ", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTreePrettyPrint(b *testing.B) {
+	data := benchDocument(10000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v, err := tree.Decode(bytes.NewReader(data))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := prettyPrint(io.Discard, v, "  ", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStreamPrint(b *testing.B) {
+	data := benchDocument(10000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := streamPrint(io.Discard, bytes.NewReader(data), "  ", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
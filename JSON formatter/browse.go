@@ -0,0 +1,513 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"example.com/json-view-formatter/query"
+	"example.com/json-view-formatter/tree"
+)
+
+// pageSize is how many children of an object or array are built at a
+// time; the rest wait behind a "more" row until asked for
+const pageSize = 200
+
+const browseHelp = "←→ fold  / search  n/N next  y copy  Y copy path  q quit"
+
+// node is a value of the document as shown by the browser. Children are
+// only built when the node is first expanded, a page at a time.
+type node struct {
+	parent   *node
+	index    int // position among the parent's children
+	depth    int
+	label    string
+	path     string
+	value    interface{}
+	children []*node
+	expanded bool
+}
+
+// size is the number of children of the value, 0 for scalars
+func (n *node) size() int {
+	switch v := n.value.(type) {
+	case tree.Object:
+		return len(v)
+	case []interface{}:
+		return len(v)
+	}
+	return 0
+}
+
+// load builds the children up to and including index i
+func (n *node) load(i int) {
+	if i >= n.size() {
+		i = n.size() - 1
+	}
+	for k := len(n.children); k <= i; k++ {
+		c := &node{parent: n, index: k, depth: n.depth + 1}
+		switch v := n.value.(type) {
+		case tree.Object:
+			c.label = v[k].Key
+			c.path = query.Join(n.path, v[k].Key)
+			c.value = v[k].Value
+		case []interface{}:
+			c.label = "[" + strconv.Itoa(k) + "]"
+			c.path = n.path + c.label
+			c.value = v[k]
+		}
+		n.children = append(n.children, c)
+	}
+}
+
+func (n *node) expand() {
+	n.expanded = true
+	if len(n.children) == 0 {
+		n.load(pageSize - 1)
+	}
+}
+
+// position is the child indexes leading from the root to n, which sort
+// in document order
+func (n *node) position() []int {
+	var pos []int
+	for ; n.parent != nil; n = n.parent {
+		pos = append([]int{n.index}, pos...)
+	}
+	return pos
+}
+
+// row is a line of the view: a node, or with more set the placeholder
+// for the children of node that are not built yet
+type row struct {
+	node *node
+	more bool
+}
+
+// browser is the state of the interactive view: the nodes built so far,
+// the rows currently visible and the cursor among them. It knows nothing
+// about the terminal; keys come in through handle and the screen goes
+// out through render.
+type browser struct {
+	root   *node
+	rows   []row
+	cursor int
+	top    int // first row on screen
+	height int // rows on screen, without the status bar
+	theme  *theme
+
+	searching bool
+	query     string
+	origin    *node // cursor when the search started, restored on esc
+	matches   [][]int
+	status    string // message shown instead of the help until the next key
+	clip      string // text to hand to the terminal clipboard
+	quit      bool
+}
+
+func newBrowser(doc interface{}, th *theme) *browser {
+	b := &browser{root: &node{label: "$", path: "$", value: doc}, theme: th, height: 1}
+	b.root.expand()
+	b.layout()
+	return b
+}
+
+// layout rebuilds the visible rows from the expanded nodes
+func (b *browser) layout() {
+	b.rows = b.rows[:0]
+	var add func(n *node)
+	add = func(n *node) {
+		b.rows = append(b.rows, row{node: n})
+		if !n.expanded {
+			return
+		}
+		for _, c := range n.children {
+			add(c)
+		}
+		if len(n.children) < n.size() {
+			b.rows = append(b.rows, row{node: n, more: true})
+		}
+	}
+	add(b.root)
+	b.move(0)
+}
+
+func (b *browser) move(delta int) {
+	b.cursor += delta
+	if b.cursor >= len(b.rows) {
+		b.cursor = len(b.rows) - 1
+	}
+	if b.cursor < 0 {
+		b.cursor = 0
+	}
+}
+
+// focus puts the cursor on n, which must be visible
+func (b *browser) focus(n *node) {
+	for i, r := range b.rows {
+		if r.node == n && !r.more {
+			b.cursor = i
+			return
+		}
+	}
+}
+
+// reveal expands the way to the node at pos and puts the cursor on it
+func (b *browser) reveal(pos []int) {
+	n := b.root
+	for _, i := range pos {
+		n.expand()
+		n.load(i)
+		n = n.children[i]
+	}
+	b.layout()
+	b.focus(n)
+}
+
+func (b *browser) handle(k string) {
+	b.status = ""
+	if b.searching {
+		b.handleSearch(k)
+		return
+	}
+	r := b.rows[b.cursor]
+	n := r.node
+	switch k {
+	case "q", "ctrl-c":
+		b.quit = true
+	case "down", "j":
+		b.move(1)
+	case "up", "k":
+		b.move(-1)
+	case "pgdn", "ctrl-f":
+		b.move(b.height)
+	case "pgup", "ctrl-b":
+		b.move(-b.height)
+	case "home", "g":
+		b.move(-len(b.rows))
+	case "end", "G":
+		b.move(len(b.rows))
+	case "right", "l":
+		switch {
+		case r.more:
+			b.loadMore(n)
+		case n.size() > 0 && !n.expanded:
+			n.expand()
+			b.layout()
+		case n.size() > 0:
+			b.move(1)
+		}
+	case "left", "h":
+		switch {
+		case r.more:
+			b.focus(n)
+		case n.expanded:
+			n.expanded = false
+			b.layout()
+			b.focus(n)
+		case n.parent != nil:
+			b.focus(n.parent)
+		}
+	case "enter", " ":
+		switch {
+		case r.more:
+			b.loadMore(n)
+		case n.expanded:
+			n.expanded = false
+			b.layout()
+			b.focus(n)
+		case n.size() > 0:
+			n.expand()
+			b.layout()
+		}
+	case "/":
+		b.searching = true
+		b.query = ""
+		b.matches = nil
+		b.origin = n
+	case "n":
+		b.next(1)
+	case "N":
+		b.next(-1)
+	case "y":
+		b.clip = compactJSON(n.value)
+		b.status = fmt.Sprintf("copied %s (%d bytes)", n.path, len(b.clip))
+	case "Y":
+		b.clip = n.path
+		b.status = "copied " + n.path
+	}
+}
+
+// loadMore builds the next page of children of n, leaving the cursor on
+// the first new one
+func (b *browser) loadMore(n *node) {
+	n.load(len(n.children) + pageSize - 1)
+	b.layout()
+}
+
+// handleSearch edits the query while searching; every change jumps to
+// the first match after where the search started
+func (b *browser) handleSearch(k string) {
+	switch k {
+	case "esc", "ctrl-c":
+		b.searching = false
+		b.query = ""
+		b.matches = nil
+		b.focus(b.origin)
+		return
+	case "enter":
+		b.searching = false
+		return
+	case "backspace":
+		if b.query == "" {
+			return
+		}
+		_, size := utf8.DecodeLastRuneInString(b.query)
+		b.query = b.query[:len(b.query)-size]
+	default:
+		if utf8.RuneCountInString(k) != 1 {
+			return
+		}
+		b.query += k
+	}
+
+	b.matches = nil
+	if b.query == "" {
+		b.focus(b.origin)
+		return
+	}
+	search(b.root.value, nil, strings.ToLower(b.query), &b.matches)
+	from := b.origin.position()
+	for _, m := range b.matches {
+		if comparePositions(m, from) >= 0 {
+			b.reveal(m)
+			return
+		}
+	}
+	if len(b.matches) > 0 {
+		b.reveal(b.matches[0])
+	}
+}
+
+// next moves to the following match, or the previous one when dir is
+// negative, wrapping around the document
+func (b *browser) next(dir int) {
+	if len(b.matches) == 0 {
+		b.status = "no matches, / to search"
+		return
+	}
+	cur := b.rows[b.cursor].node.position()
+	i := 0
+	if dir > 0 {
+		for i < len(b.matches) && comparePositions(b.matches[i], cur) <= 0 {
+			i++
+		}
+		if i == len(b.matches) {
+			i = 0
+		}
+	} else {
+		i = len(b.matches) - 1
+		for i >= 0 && comparePositions(b.matches[i], cur) >= 0 {
+			i--
+		}
+		if i < 0 {
+			i = len(b.matches) - 1
+		}
+	}
+	b.reveal(b.matches[i])
+	b.status = fmt.Sprintf("match %d of %d", i+1, len(b.matches))
+}
+
+// search appends the positions of the members whose key or scalar value
+// contains q, in document order. The whole document is searched, not
+// only the nodes built so far.
+func search(v interface{}, pos []int, q string, out *[][]int) {
+	visit := func(i int, key string, child interface{}) {
+		p := append(pos[:len(pos):len(pos)], i)
+		if strings.Contains(strings.ToLower(key), q) ||
+			(!isNested(child) && strings.Contains(strings.ToLower(scalarText(child)), q)) {
+			*out = append(*out, p)
+		}
+		search(child, p, q, out)
+	}
+	switch v := v.(type) {
+	case tree.Object:
+		for i, m := range v {
+			visit(i, m.Key, m.Value)
+		}
+	case []interface{}:
+		for i, e := range v {
+			visit(i, "", e)
+		}
+	}
+}
+
+func comparePositions(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return len(a) - len(b)
+}
+
+// stylePlain marks segments drawn without a theme color
+const stylePlain style = -1
+
+// segment is a run of text drawn in one style
+type segment struct {
+	style style
+	text  string
+}
+
+// render draws the visible rows and the status bar on a width by height
+// screen
+func (b *browser) render(w io.Writer, width, height int) {
+	b.height = height - 1
+	if b.height < 1 {
+		b.height = 1
+	}
+	if b.cursor < b.top {
+		b.top = b.cursor
+	}
+	if b.cursor >= b.top+b.height {
+		b.top = b.cursor - b.height + 1
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\x1b[H")
+	for i := b.top; i < b.top+b.height; i++ {
+		if i < len(b.rows) {
+			b.drawRow(&sb, b.rows[i], width, i == b.cursor)
+		}
+		sb.WriteString("\x1b[K\r\n")
+	}
+	b.drawStatus(&sb, width)
+	io.WriteString(w, sb.String())
+}
+
+func (b *browser) drawRow(sb *strings.Builder, r row, width int, selected bool) {
+	n := r.node
+	var segs []segment
+	if r.more {
+		segs = []segment{
+			{stylePlain, strings.Repeat("  ", n.depth+1) + "  "},
+			{stylePunct, fmt.Sprintf("… %d more, enter to load", n.size()-len(n.children))},
+		}
+	} else {
+		marker := "  "
+		if n.size() > 0 {
+			marker = "▸ "
+			if n.expanded {
+				marker = "▾ "
+			}
+		}
+		labelStyle := styleKey
+		if n.parent == nil || strings.HasPrefix(n.label, "[") {
+			labelStyle = stylePunct
+		}
+		segs = []segment{{stylePlain, strings.Repeat("  ", n.depth) + marker}, {labelStyle, clean(n.label)}, {stylePunct, ": "}}
+		switch v := n.value.(type) {
+		case tree.Object:
+			segs = append(segs, segment{stylePunct, fmt.Sprintf("{%d keys}", len(v))})
+		case []interface{}:
+			segs = append(segs, segment{stylePunct, fmt.Sprintf("[%d items]", len(v))})
+		default:
+			segs = append(segs, segment{valueStyle(v), clean(scalarText(v))})
+		}
+	}
+	segs = fit(segs, width)
+
+	if selected {
+		var line strings.Builder
+		for _, s := range segs {
+			line.WriteString(s.text)
+		}
+		sb.WriteString("\x1b[7m")
+		sb.WriteString(line.String())
+		sb.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(line.String())))
+		sb.WriteString("\x1b[0m")
+		return
+	}
+	for _, s := range segs {
+		sb.WriteString(b.theme.paint(s.style, s.text))
+	}
+}
+
+// drawStatus writes the bar at the bottom: the path at the cursor or the
+// search being typed, and the help or a message
+func (b *browser) drawStatus(sb *strings.Builder, width int) {
+	left := b.rows[b.cursor].node.path
+	right := browseHelp
+	if b.status != "" {
+		right = b.status
+	}
+	if b.searching {
+		left = "/" + b.query
+		right = fmt.Sprintf("%d matches, enter to keep, esc to cancel", len(b.matches))
+	}
+	left = clean(left)
+	line := left
+	if gap := width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right); gap >= 2 {
+		line = left + strings.Repeat(" ", gap) + right
+	}
+	line = truncate(line, width)
+	sb.WriteString("\x1b[7m")
+	sb.WriteString(line)
+	sb.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(line)))
+	sb.WriteString("\x1b[0m")
+}
+
+// fit cuts segments down to width runes, marking a cut with an ellipsis
+func fit(segs []segment, width int) []segment {
+	used := 0
+	for i, s := range segs {
+		n := utf8.RuneCountInString(s.text)
+		if used+n > width {
+			segs[i].text = truncate(s.text, width-used)
+			return segs[:i+1]
+		}
+		used += n
+	}
+	return segs
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	r := []rune(s)
+	return string(r[:width-1]) + "…"
+}
+
+// clean escapes control characters so a value stays on its row
+func clean(s string) string {
+	if strings.IndexFunc(s, unicode.IsControl) < 0 {
+		return s
+	}
+	q := strconv.Quote(s)
+	return q[1 : len(q)-1]
+}
+
+// scalarText is a leaf value as the tree view prints it
+func scalarText(v interface{}) string {
+	return (&printer{}).scalar(v)
+}
+
+func valueStyle(v interface{}) style {
+	switch v.(type) {
+	case nil:
+		return styleNull
+	case string:
+		return styleString
+	case bool:
+		return styleBool
+	}
+	return styleNumber
+}
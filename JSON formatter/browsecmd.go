@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// runBrowse opens one document in the interactive tree browser. The
+// document is parsed as by the viewer and shown with the same theme; keys
+// come from the terminal, also when the document is read from stdin.
+func runBrowse(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("jsonFormatViewer browse", "[file|-]", stderr)
+	colorMode := flags.String("color", "auto", "color output: auto, always or never; auto honors NO_COLOR")
+	themeName := flags.String("theme", "default", "color theme: "+strings.Join(themeNames(), ", ")+" or a theme file")
+	inputName := flags.String("input", "auto", inputUsage)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	path := defaultConfigPath
+	if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+	input, err := parseInput(*inputName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	th, err := loadTheme(*themeName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	color, err := useColor(*colorMode, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if !color {
+		th = nil
+	}
+
+	out, ok := stdout.(*os.File)
+	if !ok || !isTerminal(out) {
+		fmt.Fprintln(stderr, "browse needs a terminal, use the tree view to print")
+		return exitUsage
+	}
+	doc, code := loadDocument(stderr, stdin, path, input)
+	if code != exitOK {
+		return code
+	}
+
+	keys, err := keyboard(stdin, path)
+	if err != nil {
+		fmt.Fprintf(stderr, "browse: no terminal to read keys from: %v\n", err)
+		return exitUsage
+	}
+	if keys != stdin {
+		defer keys.Close()
+	}
+
+	if err := browseTerminal(keys, out, newBrowser(doc, th)); err != nil {
+		return reportError(stderr, displayName(path), &writeError{err})
+	}
+	return exitOK
+}
+
+// keyboard returns the terminal to read keys from: stdin, unless the
+// document came from there, and the controlling terminal otherwise
+func keyboard(stdin io.Reader, path string) (*os.File, error) {
+	if f, ok := stdin.(*os.File); ok && path != stdinName && isTerminal(f) {
+		return f, nil
+	}
+	return os.Open("/dev/tty")
+}
@@ -0,0 +1,590 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"example.com/json-view-formatter/decode"
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/query"
+	"example.com/json-view-formatter/redact"
+	"example.com/json-view-formatter/schema"
+	"example.com/json-view-formatter/tree"
+)
+
+// exit codes, one per failure class. When several documents fail in one
+// run the highest code wins.
+const (
+	exitOK          = 0
+	exitUsage       = 1
+	exitUnreadable  = 2
+	exitInvalid     = 3
+	exitPartial     = 4
+	exitNoMatch     = 5
+	exitUnsupported = 6
+	exitSchema      = 7
+	exitDiffers     = 8
+	exitPatchFailed = 9
+	exitLint        = 10
+)
+
+// stdinName is the argument that makes the viewer read standard input
+const stdinName = "-"
+
+// defaultConfigPath is viewed when no arguments are given
+var defaultConfigPath = filepath.Join("cfg", "config.json")
+
+// options collects the flags that shape how documents are viewed
+type options struct {
+	stream    bool
+	ndjson    bool
+	allErrors bool
+	// normalize rewrites numbers in canonical form instead of as written
+	normalize bool
+	// stats replaces the document with a summary of its shape
+	stats bool
+	// redactor masks secrets before anything is shown, nil when off
+	redactor *redact.Redactor
+	query    *query.Query
+	theme    *theme
+	// input forces an input format, otherwise it follows the extension
+	input decode.Format
+	// schema switches to validation: violations are listed instead of the
+	// document being printed
+	schema     *schema.Schema
+	schemaName string
+	// encoder replaces the tree view when another output format is chosen
+	encoder encode.Encoder
+	// html writes the tree as a standalone HTML page instead
+	html bool
+}
+
+// subcommands run instead of the viewer when named by the first argument
+var subcommands = map[string]func(args []string, stdin io.Reader, stdout, stderr io.Writer) int{
+	"browse": runBrowse,
+	"config": runConfig,
+	"delete": runDelete,
+	"diff":   runDiff,
+	"infer":  runInfer,
+	"lint":   runLint,
+	"patch":  runPatch,
+	"rename": runRename,
+	"render": runRender,
+	"set":    runSet,
+}
+
+// newFlagSet returns a flag set printing its usage line with operands to
+// stderr
+func newFlagSet(name, operands string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: %s [flags] %s\n", flags.Name(), operands)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses args; when that does not succeed it returns false
+// with the exit code to stop with
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// inputUsage documents every -input flag
+var inputUsage = "input `format`: auto (by extension), " + strings.Join(decode.Formats(), ", ")
+
+// parseInput resolves an -input flag, auto becoming "" for detection by
+// extension
+func parseInput(name string) (decode.Format, error) {
+	if name == "auto" {
+		return "", nil
+	}
+	return decode.ParseFormat(name)
+}
+
+// run is main without the os.Exit so the whole command can be driven
+// with arbitrary streams
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		if cmd, ok := subcommands[args[0]]; ok {
+			return cmd(args[1:], stdin, stdout, stderr)
+		}
+	}
+
+	var opts options
+	flags := newFlagSet("jsonFormatViewer", "[file|glob|-]...", stderr)
+	flags.BoolVar(&opts.stream, "stream", false, "render while reading, without loading the document into memory")
+	flags.BoolVar(&opts.ndjson, "ndjson", false, "read one JSON document per line (JSON Lines); on by default for .ndjson and .jsonl files")
+	flags.BoolVar(&opts.normalize, "normalize-numbers", false, "print numbers in one canonical form, e.g. 1.50 and 15e-1 as 1.5, without losing precision")
+	watch := flags.Bool("watch", false, "keep running and show the files again whenever they are saved, marking what changed")
+	flags.BoolVar(&opts.stats, "stats", false, "print depth, type counts, largest arrays, longest strings, key paths and subtree sizes instead of the document; -o json for machine-readable output")
+	flags.BoolVar(&opts.allErrors, "all-errors", false, "on invalid input list every syntax problem instead of the first")
+	colorMode := flags.String("color", "auto", "color output: auto, always or never; auto honors NO_COLOR")
+	themeName := flags.String("theme", "default", "color theme: "+strings.Join(themeNames(), ", ")+" or a theme file")
+	inputName := flags.String("input", "auto", inputUsage)
+	format := flags.String("o", "tree", "output `format`: tree, html, "+strings.Join(encode.Formats(), ", "))
+	var encOpts encode.Options
+	flags.IntVar(&encOpts.Indent, "indent", encode.DefaultOptions.Indent, "indent width of -o json")
+	flags.StringVar(&encOpts.XMLRoot, "xml-root", encode.DefaultOptions.XMLRoot, "document element name of -o xml")
+	flags.StringVar(&encOpts.XMLArray, "xml-array", encode.DefaultOptions.XMLArray, "array entry naming of -o xml: item, singular, repeat or a fixed element name")
+	flags.IntVar(&encOpts.ColumnWidth, "max-width", encode.DefaultOptions.ColumnWidth, "column width cap of -o table and markdown, in characters")
+	flags.StringVar(&opts.schemaName, "schema", "", "validate against a JSON Schema (draft 2020-12) `file` instead of printing")
+	redactOn := flags.Bool("redact", false, "mask secrets: members with secret-looking keys, JWTs, AWS keys and passwords in URLs")
+	redactKeys := flags.String("redact-keys", "", "comma-separated key `patterns` to mask instead of the defaults, e.g. '*password*,token'; implies -redact")
+	queryExpr := flags.String("query", "", "only print the values matched by a JSONPath/jq style `path`, e.g. items[*].price")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if opts.stream && opts.ndjson {
+		fmt.Fprintln(stderr, "-ndjson cannot be combined with -stream")
+		return exitUsage
+	}
+	if opts.stats && (opts.stream || opts.ndjson) {
+		fmt.Fprintln(stderr, "-stats cannot be combined with -stream or -ndjson")
+		return exitUsage
+	}
+	if *watch && (opts.stream || opts.ndjson || opts.stats) {
+		fmt.Fprintln(stderr, "-watch cannot be combined with -stream, -ndjson or -stats")
+		return exitUsage
+	}
+	if opts.stream && opts.normalize {
+		fmt.Fprintln(stderr, "-normalize-numbers cannot be combined with -stream")
+		return exitUsage
+	}
+	if *redactOn || *redactKeys != "" {
+		if opts.stream {
+			fmt.Fprintln(stderr, "-redact cannot be combined with -stream")
+			return exitUsage
+		}
+		keys := redact.DefaultKeys
+		if *redactKeys != "" {
+			keys = strings.Split(*redactKeys, ",")
+		}
+		r, err := redact.New(keys)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		opts.redactor = r
+	}
+	if *queryExpr != "" {
+		if opts.stream {
+			fmt.Fprintln(stderr, "-query cannot be combined with -stream")
+			return exitUsage
+		}
+		q, err := query.Parse(*queryExpr)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		opts.query = q
+	}
+
+	input, err := parseInput(*inputName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	opts.input = input
+
+	if *format != "tree" {
+		if opts.stream {
+			fmt.Fprintln(stderr, "-o cannot be combined with -stream")
+			return exitUsage
+		}
+		if *format == "html" {
+			opts.html = true
+		} else if opts.encoder, err = encode.New(*format, encOpts); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+	}
+
+	if opts.schemaName != "" {
+		sch, err := loadSchema(opts.schemaName)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		opts.schema = sch
+	}
+
+	th, err := loadTheme(*themeName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	color, err := useColor(*colorMode, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if color {
+		opts.theme = th
+	}
+
+	paths, err := expandArgs(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	if *watch {
+		if *format != "tree" {
+			fmt.Fprintln(stderr, "-watch shows the tree view, it cannot be combined with -o")
+			return exitUsage
+		}
+		for _, path := range paths {
+			if path == stdinName || isNDJSON(path) {
+				fmt.Fprintf(stderr, "-watch cannot follow %s\n", displayName(path))
+				return exitUsage
+			}
+		}
+		return watchFiles(stdout, stderr, paths, &opts)
+	}
+
+	out := bufio.NewWriter(stdout)
+	code := exitOK
+	for _, path := range paths {
+		if c := viewFile(out, stderr, stdin, path, &opts); c > code {
+			code = c
+		}
+	}
+	return code
+}
+
+// expandArgs turns the command line into the list of documents to view.
+// Globs are expanded, "-" stands for stdin and a pattern matching nothing
+// is kept as is so the read error names it.
+func expandArgs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{defaultConfigPath}, nil
+	}
+	var paths []string
+	for _, arg := range args {
+		if arg == stdinName || !strings.ContainsAny(arg, "*?[") {
+			paths = append(paths, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %v", arg, err)
+		}
+		if len(matches) == 0 {
+			paths = append(paths, arg)
+			continue
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+func displayName(path string) string {
+	if path == stdinName {
+		return "<stdin>"
+	}
+	return path
+}
+
+// openSource opens the document named by path, "-" being stdin
+func openSource(path string, stdin io.Reader) (io.ReadCloser, error) {
+	if path == stdinName {
+		return io.NopCloser(stdin), nil
+	}
+	return os.Open(path)
+}
+
+// readSource reads the whole document named by path
+func readSource(path string, stdin io.Reader) ([]byte, error) {
+	src, err := openSource(path, stdin)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return io.ReadAll(src)
+}
+
+// decodeData parses data in the given format, reporting problems for the
+// document name the way the viewer does. The code is exitOK on success.
+func decodeData(stderr io.Writer, name string, format decode.Format, data []byte, all bool) (interface{}, int) {
+	// Parse keeping the source order of keys; the decoder validates as it
+	// goes so the document is only parsed once
+	result, err := decode.Decode(format, data)
+	if err == nil {
+		return result, exitOK
+	}
+	var se *decode.SyntaxError
+	switch {
+	case format == decode.JSON:
+		return nil, reportSyntax(stderr, name, data, err, all)
+	case errors.As(err, &se):
+		fmt.Fprintf(stderr, "invalid %s file - %s\n", format, name)
+		writeSyntaxReport(stderr, name, data, []syntaxIssue{{offset: se.Offset, msg: se.Msg}})
+		return nil, exitInvalid
+	}
+	fmt.Fprintf(stderr, "invalid %s file - %s: %v\n", format, name, err)
+	return nil, exitInvalid
+}
+
+// loadDocument reads and decodes the document at path for the
+// subcommands, detecting the format from the extension unless input is set
+func loadDocument(stderr io.Writer, stdin io.Reader, path string, input decode.Format) (interface{}, int) {
+	name := displayName(path)
+	data, err := readSource(path, stdin)
+	if err != nil {
+		return nil, reportError(stderr, name, err)
+	}
+	if input == "" {
+		input = decode.Detect(path)
+	}
+	return decodeData(stderr, name, input, data, false)
+}
+
+// viewFile prints one document under its own header and returns the exit
+// code for it
+func viewFile(out *bufio.Writer, stderr io.Writer, stdin io.Reader, path string, opts *options) int {
+	name := displayName(path)
+
+	src, err := openSource(path, stdin)
+	if err != nil {
+		return reportError(stderr, name, err)
+	}
+	defer src.Close()
+
+	format := opts.input
+	if format == "" {
+		format = decode.Detect(path)
+	}
+
+	if opts.ndjson || (opts.input == "" && !opts.stream && isNDJSON(path)) {
+		if format != decode.JSON {
+			fmt.Fprintf(stderr, "%s: -ndjson reads JSON lines, not %s\n", name, format)
+			return exitUsage
+		}
+		if opts.html {
+			fmt.Fprintf(stderr, "%s: -o html shows one document, not JSON lines\n", name)
+			return exitUsage
+		}
+		return viewLines(out, stderr, src, name, opts)
+	}
+
+	if opts.stream {
+		if format != decode.JSON {
+			fmt.Fprintf(stderr, "%s: only JSON input can be streamed, not %s\n", name, format)
+			return exitUsage
+		}
+		fmt.Fprintf(out, "=== %s ===\n", name)
+		err = streamPrint(out, src, "  ", opts.theme)
+		// flush what was printed before the error is reported
+		if flushErr := out.Flush(); err == nil && flushErr != nil {
+			err = &writeError{flushErr}
+		}
+		if err != nil && classify(err) == exitInvalid && path != stdinName {
+			// the file is read again only to explain the error
+			if data, readErr := os.ReadFile(path); readErr == nil {
+				return reportSyntax(stderr, name, data, err, opts.allErrors)
+			}
+		}
+		if err != nil {
+			return reportError(stderr, name, err)
+		}
+		return exitOK
+	}
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return reportError(stderr, name, err)
+	}
+
+	result, code := decodeData(stderr, name, format, data, opts.allErrors)
+	if code != exitOK {
+		return code
+	}
+	if opts.normalize {
+		result = tree.NormalizeNumbers(result)
+	}
+
+	if opts.schema != nil {
+		return validateDocument(out, stderr, name, opts, result)
+	}
+
+	if result, code = applyOptions(stderr, name, opts, result); code != exitOK {
+		return code
+	}
+
+	if opts.stats {
+		s := collectStats(result)
+		if opts.encoder != nil {
+			return writeEncoded(out, stderr, name, opts.encoder, s.tree())
+		}
+		if opts.html {
+			err = writeHTML(out, name+" statistics", s.tree())
+		} else {
+			fmt.Fprintf(out, "=== %s ===\n", name)
+			err = writeStats(out, s)
+		}
+	} else if opts.encoder != nil {
+		return writeEncoded(out, stderr, name, opts.encoder, result)
+	} else if opts.html {
+		err = writeHTML(out, name, result)
+	} else {
+		fmt.Fprintf(out, "=== %s ===\n", name)
+		err = prettyPrint(out, result, "  ", opts.theme)
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		return reportError(stderr, name, &writeError{err})
+	}
+	return exitOK
+}
+
+// writeEncoded writes the document in another output format. Those are
+// meant for other programs, so no header is printed.
+func writeEncoded(out *bufio.Writer, stderr io.Writer, name string, enc encode.Encoder, v interface{}) int {
+	err := enc.Encode(out, v)
+	var ue *encode.UnrepresentableError
+	if errors.As(err, &ue) {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return exitUnsupported
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		return reportError(stderr, name, &writeError{err})
+	}
+	return exitOK
+}
+
+// loadSchema reads and compiles a schema file in any input format
+func loadSchema(path string) (*schema.Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decode.Decode(decode.Detect(path), data)
+	if err != nil {
+		return nil, fmt.Errorf("schema %s: %v", path, err)
+	}
+	return schema.Compile(doc)
+}
+
+// validateDocument lists every schema violation of the document by JSON
+// Pointer and returns exitSchema when there is any
+func validateDocument(out *bufio.Writer, stderr io.Writer, name string, opts *options, doc interface{}) int {
+	violations := opts.schema.Validate(doc)
+	if len(violations) == 0 {
+		fmt.Fprintf(out, "%s: valid against %s\n", name, opts.schemaName)
+		if err := out.Flush(); err != nil {
+			return reportError(stderr, name, &writeError{err})
+		}
+		return exitOK
+	}
+	for _, v := range violations {
+		fmt.Fprintf(stderr, "%s: %s\n", name, v)
+	}
+	fmt.Fprintf(stderr, "%s: %d schema violations\n", name, len(violations))
+	return exitSchema
+}
+
+// applyOptions redacts and queries a decoded document as the options ask
+func applyOptions(stderr io.Writer, name string, opts *options, result interface{}) (interface{}, int) {
+	if opts.redactor != nil {
+		var found []redact.Finding
+		result, found = opts.redactor.Redact(result)
+		reportRedactions(stderr, name, found)
+	}
+	if opts.query != nil {
+		var ok bool
+		if result, ok = selectResult(opts.query, result); !ok {
+			fmt.Fprintf(stderr, "query %s matched nothing in %s\n", opts.query, name)
+			return nil, exitNoMatch
+		}
+	}
+	return result, exitOK
+}
+
+// reportRedactions tells on stderr what was masked, keeping the output
+// itself clean for pasting
+func reportRedactions(stderr io.Writer, name string, found []redact.Finding) {
+	if len(found) == 0 {
+		return
+	}
+	fmt.Fprintf(stderr, "%s: redacted %d values\n", name, len(found))
+	for _, f := range found {
+		fmt.Fprintf(stderr, "  %s\n", f)
+	}
+}
+
+// selectResult narrows the document to what q matched. A query that can
+// match at most one value yields that value, any other query the list of
+// matches.
+func selectResult(q *query.Query, doc interface{}) (interface{}, bool) {
+	matches := q.Eval(doc)
+	if !q.Definite() {
+		if matches == nil {
+			matches = []interface{}{}
+		}
+		return matches, true
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+	return matches[0], true
+}
+
+// classify returns the exit code of err's failure class
+func classify(err error) int {
+	var we *writeError
+	var pe *fs.PathError
+	switch {
+	case errors.As(err, &we):
+		return exitPartial
+	case errors.As(err, &pe):
+		return exitUnreadable
+	}
+	return exitInvalid
+}
+
+// reportError prints err for the document name and returns the exit code
+// of its failure class
+func reportError(stderr io.Writer, name string, err error) int {
+	code := classify(err)
+	switch code {
+	case exitPartial:
+		fmt.Fprintf(stderr, "error writing %s: %v\n", name, err)
+	case exitUnreadable:
+		fmt.Fprintf(stderr, "error reading file %s: %v\n", name, err)
+	default:
+		fmt.Fprintf(stderr, "invalid JSON file - %s: %v\n", name, err)
+	}
+	return code
+}
+
+// reportSyntax explains where and why data is not valid JSON. err is the
+// decoder's error, shown when the checker finds nothing to point at.
+func reportSyntax(stderr io.Writer, name string, data []byte, err error, all bool) int {
+	issues := checkSyntax(data, all)
+	if len(issues) == 0 {
+		return reportError(stderr, name, err)
+	}
+	fmt.Fprintf(stderr, "invalid JSON file - %s\n", name)
+	writeSyntaxReport(stderr, name, data, issues)
+	return exitInvalid
+}
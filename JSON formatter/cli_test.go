@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCLI runs the command line args with stdin as standard input and
+// returns what it wrote and its exit code
+func runCLI(t *testing.T, stdin string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	var out, errOut strings.Builder
+	code = run(args, strings.NewReader(stdin), &out, &errOut)
+	return out.String(), errOut.String(), code
+}
+
+// writeFiles creates files from name/content pairs in a temporary
+// directory and returns its path
+func writeFiles(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for i := 0; i+1 < len(files); i += 2 {
+		if err := os.WriteFile(filepath.Join(dir, files[i]), []byte(files[i+1]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// failWriter fails every write, like a closed pipe
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestView(t *testing.T) {
+	const doc = `{"a": [1, {}], "b": null}`
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+		want  string
+	}{
+		{"tree", []string{"-"}, doc, "=== <stdin> ===\n  a:\n    [0]: 1\n    [1]: {}\n  b: null\n"},
+		{"stream matches the tree", []string{"-stream", "-"}, doc, "=== <stdin> ===\n  a:\n    [0]: 1\n    [1]: {}\n  b: null\n"},
+		{"definite query", []string{"-query", "a[0]", "-"}, doc, "=== <stdin> ===\n  1\n"},
+		{"query listing matches", []string{"-query", "a[*]", "-o", "compact", "-"}, doc, "[1,{}]\n"},
+		{"output format", []string{"-o", "compact", "-"}, doc, `{"a":[1,{}],"b":null}` + "\n"},
+		{"numbers as written", []string{"-o", "compact", "-"}, `[1.50, 1e2]`, "[1.50,1e2]\n"},
+		{"normalized numbers", []string{"-normalize-numbers", "-o", "compact", "-"}, `[1.50, 1e2]`, "[1.5,100]\n"},
+		{"input format", []string{"-input", "yaml", "-o", "compact", "-"}, "a: [1]\n", `{"a":[1]}` + "\n"},
+		{"redaction", []string{"-redact", "-o", "compact", "-"}, `{"password":"hunter2"}`, `{"password":"[REDACTED]"}` + "\n"},
+		{"ndjson", []string{"-ndjson", "-o", "compact", "-"}, "{\"a\":1}\n{\"a\":2}\n", "{\"a\":1}\n{\"a\":2}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, errOut, code := runCLI(t, tt.stdin, tt.args...)
+			if code != exitOK {
+				t.Fatalf("exit %d; stderr: %s", code, errOut)
+			}
+			if out != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	dir := writeFiles(t,
+		"ok.json", `{"a":1,"items":[{"id":1},{"id":2}]}`,
+		"other.json", `{"a":2,"items":[{"id":1},{"id":2}]}`,
+		"bad.json", `{"a":1,}`,
+		"bad.yaml", "a: [1\n",
+		"dup.json", `{"a":1,"a":2}`,
+		"mixed.json", `[1,"a"]`,
+		"schema.json", `{"properties":{"a":{"type":"string"}}}`,
+		"patch.json", `[{"op":"test","path":"/a","value":2}]`,
+		"base.json", `{"a":1}`,
+		"t.tmpl", `{{.nope}}`,
+	)
+	file := func(name string) string { return filepath.Join(dir, name) }
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"view", []string{file("ok.json")}, exitOK},
+		{"help", []string{"-h"}, exitOK},
+		{"unknown flag", []string{"-nope"}, exitUsage},
+		{"unreadable", []string{file("missing.json")}, exitUnreadable},
+		{"invalid JSON", []string{file("bad.json")}, exitInvalid},
+		{"invalid YAML", []string{file("bad.yaml")}, exitInvalid},
+		{"the highest code wins", []string{file("missing.json"), file("bad.json"), file("ok.json")}, exitInvalid},
+		{"glob", []string{filepath.Join(dir, "o*.json")}, exitOK},
+		{"query matching nothing", []string{"-query", "nope", file("ok.json")}, exitNoMatch},
+		{"bad query", []string{"-query", "a[", file("ok.json")}, exitUsage},
+		{"unrepresentable", []string{"-o", "toml", file("mixed.json")}, exitUnsupported},
+		{"unknown output format", []string{"-o", "nope", file("ok.json")}, exitUsage},
+		{"schema violation", []string{"-schema", file("schema.json"), file("ok.json")}, exitSchema},
+		{"schema met", []string{"-schema", file("schema.json"), file("mixed.json")}, exitOK},
+		{"missing schema", []string{"-schema", file("missing.json"), file("ok.json")}, exitUsage},
+
+		{"diff equal", []string{"diff", file("ok.json"), file("ok.json")}, exitOK},
+		{"diff differs", []string{"diff", file("ok.json"), file("other.json")}, exitDiffers},
+		{"diff by key", []string{"diff", "-key", "id", "-patch", file("ok.json"), file("other.json")}, exitDiffers},
+		{"diff unreadable", []string{"diff", file("ok.json"), file("missing.json")}, exitUnreadable},
+		{"patch", []string{"patch", file("other.json"), file("patch.json")}, exitOK},
+		{"patch failed", []string{"patch", file("ok.json"), file("patch.json")}, exitPatchFailed},
+		{"lint clean", []string{"lint", file("ok.json")}, exitOK},
+		{"lint warning", []string{"lint", file("mixed.json")}, exitOK},
+		{"lint error", []string{"lint", file("dup.json")}, exitLint},
+		{"lint rule raised", []string{"lint", "-rules", "mixed-array=error", file("mixed.json")}, exitLint},
+		{"lint rule off", []string{"lint", "-rules", "duplicate-key=off", file("dup.json")}, exitOK},
+		{"lint unknown rule", []string{"lint", "-rules", "nope=error", file("ok.json")}, exitUsage},
+		{"lint invalid", []string{"lint", file("bad.json")}, exitInvalid},
+		{"infer", []string{"infer", file("ok.json"), file("mixed.json")}, exitOK},
+		{"infer bad format", []string{"infer", "-o", "rust", file("ok.json")}, exitUsage},
+		{"config", []string{"config", "-env-prefix", "", file("base.json"), file("ok.json")}, exitOK},
+		{"config unreadable", []string{"config", file("missing.json")}, exitUnreadable},
+		{"render missing key", []string{"render", "-template", file("t.tmpl"), file("ok.json")}, exitNoMatch},
+		{"delete missing path", []string{"delete", "nope", file("base.json")}, exitNoMatch},
+		{"set without a value", []string{"set", "a"}, exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errOut, code := runCLI(t, "", tt.args...)
+			if code != tt.code {
+				t.Errorf("exit %d, want %d; stderr: %s", code, tt.code, errOut)
+			}
+		})
+	}
+}
+
+// flags that cannot work together are refused before anything is read
+func TestFlagConflicts(t *testing.T) {
+	for _, args := range [][]string{
+		{"-stream", "-ndjson"},
+		{"-stream", "-stats"},
+		{"-ndjson", "-stats"},
+		{"-stream", "-normalize-numbers"},
+		{"-stream", "-redact"},
+		{"-stream", "-redact-keys", "token"},
+		{"-stream", "-query", "a"},
+		{"-stream", "-o", "yaml"},
+		{"-watch", "-stream"},
+		{"-watch", "-stats"},
+		{"-watch", "-o", "json", "x.json"},
+		{"-watch", "-"},
+		{"-color", "sometimes"},
+		{"-theme", "missing-theme-file.json"},
+		{"-input", "ini"},
+		{"-o", "html", "-ndjson", "-"},
+		{"-ndjson", "-input", "yaml", "-"},
+		{"-stream", "-input", "yaml", "-"},
+	} {
+		out, errOut, code := runCLI(t, "{}\n", args...)
+		if code != exitUsage {
+			t.Errorf("%q: exit %d, want %d", args, code, exitUsage)
+		}
+		if errOut == "" {
+			t.Errorf("%q: nothing on stderr", args)
+		}
+		if out != "" {
+			t.Errorf("%q: wrote %q", args, out)
+		}
+	}
+}
+
+func TestPartialOutput(t *testing.T) {
+	for _, args := range [][]string{
+		{"-"},
+		{"-stream", "-"},
+		{"-o", "json", "-"},
+		{"-stats", "-"},
+		{"-ndjson", "-"},
+	} {
+		var errOut strings.Builder
+		code := run(args, strings.NewReader(`{"a":1}`), failWriter{}, &errOut)
+		if code != exitPartial {
+			t.Errorf("%q: exit %d, want %d; stderr: %s", args, code, exitPartial, errOut.String())
+		}
+	}
+}
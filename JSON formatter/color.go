@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// theme holds the ANSI SGR parameters (e.g. "1;34") used for each part of
+// the output. An empty field leaves that part undecorated, a nil theme
+// turns color off.
+type theme struct {
+	Key    string `json:"key"`
+	String string `json:"string"`
+	Number string `json:"number"`
+	Bool   string `json:"bool"`
+	Null   string `json:"null"`
+	Punct  string `json:"punct"`
+}
+
+// builtinThemes are selectable by name with -theme
+var builtinThemes = map[string]*theme{
+	"default": {
+		Key:    "1;34",
+		String: "32",
+		Number: "36",
+		Bool:   "33",
+		Null:   "1;30",
+		Punct:  "37",
+	},
+	"solarized": {
+		Key:    "38;5;33",
+		String: "38;5;64",
+		Number: "38;5;37",
+		Bool:   "38;5;136",
+		Null:   "38;5;245",
+		Punct:  "38;5;240",
+	},
+	"monokai": {
+		Key:    "38;5;197",
+		String: "38;5;186",
+		Number: "38;5;141",
+		Bool:   "38;5;208",
+		Null:   "38;5;242",
+		Punct:  "38;5;231",
+	},
+	"mono": {
+		Key:    "1",
+		Null:   "2",
+		Punct:  "2",
+		Bool:   "4",
+		Number: "",
+		String: "",
+	},
+}
+
+// colorNames lets theme files say "bold blue" instead of "1;34"
+var colorNames = map[string]string{
+	"bold": "1", "dim": "2", "italic": "3", "underline": "4", "reverse": "7",
+	"black": "30", "red": "31", "green": "32", "yellow": "33",
+	"blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+	"bright-black": "90", "bright-red": "91", "bright-green": "92", "bright-yellow": "93",
+	"bright-blue": "94", "bright-magenta": "95", "bright-cyan": "96", "bright-white": "97",
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadTheme returns the built-in theme called name, or reads a theme file
+// when name is a path. Styles missing from a file fall back to default.
+func loadTheme(name string) (*theme, error) {
+	if t, ok := builtinThemes[name]; ok {
+		return t, nil
+	}
+	if !strings.ContainsAny(name, `/\.`) {
+		return nil, fmt.Errorf("unknown theme %q, want one of %s or a theme file", name, strings.Join(themeNames(), ", "))
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	t := *builtinThemes["default"]
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("theme file %s: %v", name, err)
+	}
+	for _, field := range []*string{&t.Key, &t.String, &t.Number, &t.Bool, &t.Null, &t.Punct} {
+		if *field, err = parseStyle(*field); err != nil {
+			return nil, fmt.Errorf("theme file %s: %v", name, err)
+		}
+	}
+	return &t, nil
+}
+
+// parseStyle turns "bold blue" or "1;34" into SGR parameters
+func parseStyle(s string) (string, error) {
+	var codes []string
+	for _, word := range strings.Fields(strings.ToLower(s)) {
+		if code, ok := colorNames[word]; ok {
+			codes = append(codes, code)
+			continue
+		}
+		for _, part := range strings.Split(word, ";") {
+			if _, err := strconv.Atoi(part); err != nil {
+				return "", fmt.Errorf("unknown style %q", word)
+			}
+		}
+		codes = append(codes, word)
+	}
+	return strings.Join(codes, ";"), nil
+}
+
+// style names a part of the output a theme decorates
+type style int
+
+const (
+	styleKey style = iota
+	styleString
+	styleNumber
+	styleBool
+	styleNull
+	stylePunct
+)
+
+func (t *theme) code(s style) string {
+	switch s {
+	case styleKey:
+		return t.Key
+	case styleString:
+		return t.String
+	case styleNumber:
+		return t.Number
+	case styleBool:
+		return t.Bool
+	case styleNull:
+		return t.Null
+	}
+	return t.Punct
+}
+
+// paint wraps text in the escape sequence for s
+func (t *theme) paint(s style, text string) string {
+	if t == nil {
+		return text
+	}
+	code := t.code(s)
+	if code == "" {
+		return text
+	}
+	return "\x1b[" + code + "m" + text + "\x1b[0m"
+}
+
+// useColor decides whether output to w gets colored. mode is the -color
+// flag: "always", "never" or "auto", where auto means a terminal without
+// NO_COLOR set.
+func useColor(mode string, w io.Writer) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false, nil
+		}
+		return isTerminal(w), nil
+	}
+	return false, fmt.Errorf("bad -color %q, want auto, always or never", mode)
+}
+
+// isTerminal reports whether w is a terminal; other character devices
+// such as /dev/null are not
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/layered"
+	"example.com/json-view-formatter/tree"
+)
+
+// runConfig shows the configuration a service gets from layered files:
+// merged in order, ${VAR} references expanded and environment overrides
+// applied, every value annotated with where it came from
+func runConfig(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("jsonFormatViewer config", "base [overlay...]", stderr)
+	prefix := flags.String("env-prefix", "APP_", "environment variables with this `prefix` override values, APP_SHIPTO_CITY setting shipTo.city; empty for none")
+	expand := flags.Bool("expand", true, "expand ${VAR} and ${VAR:-default} in string values")
+	annotate := flags.Bool("annotate", true, "annotate every value in the tree with its file or variable")
+	format := flags.String("o", "tree", "output `format`: tree, "+strings.Join(encode.Formats(), ", "))
+	colorMode := flags.String("color", "auto", "color output: auto, always or never; auto honors NO_COLOR")
+	themeName := flags.String("theme", "default", "color theme: "+strings.Join(themeNames(), ", ")+" or a theme file")
+	inputName := flags.String("input", "auto", inputUsage)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	input, err := parseInput(*inputName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	var enc encode.Encoder
+	if *format != "tree" {
+		if enc, err = encode.New(*format, encode.DefaultOptions); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+	}
+	th, err := loadTheme(*themeName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	color, err := useColor(*colorMode, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if !color {
+		th = nil
+	}
+
+	var sources []layered.Source
+	for _, path := range flags.Args() {
+		doc, code := loadDocument(stderr, stdin, path, input)
+		if code != exitOK {
+			return code
+		}
+		sources = append(sources, layered.Source{Name: displayName(path), Doc: doc})
+	}
+	cfg := layered.Merge(sources)
+	if *expand {
+		cfg.Expand(os.LookupEnv)
+		for _, name := range cfg.Unset {
+			fmt.Fprintf(stderr, "config: ${%s} is not set and has no default\n", name)
+		}
+	}
+	if *prefix != "" {
+		cfg.Override(*prefix, os.Environ())
+		for _, name := range cfg.Skipped {
+			fmt.Fprintf(stderr, "config: %s names an array element that does not exist, ignored\n", name)
+		}
+	}
+
+	out := bufio.NewWriter(stdout)
+	if enc != nil {
+		return writeEncoded(out, stderr, "config", enc, cfg.Doc)
+	}
+	if *annotate {
+		err = printAnnotated(out, cfg, th)
+	} else {
+		err = prettyPrint(out, cfg.Doc, "", th)
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		return reportError(stderr, "config", &writeError{err})
+	}
+	return exitOK
+}
+
+// printAnnotated prints the tree view of a merged configuration with the
+// origin of every scalar and empty container as a # comment after it
+func printAnnotated(w io.Writer, cfg *layered.Config, th *theme) error {
+	p := &printer{errWriter: errWriter{w: w}, theme: th}
+	note := func(path []string) string {
+		origin := cfg.Origin(tree.Pointer(path...))
+		if origin == "" {
+			return ""
+		}
+		return "  " + p.theme.paint(stylePunct, "# "+origin)
+	}
+	var walk func(v interface{}, indent string, path []string)
+	walk = func(v interface{}, indent string, path []string) {
+		eachMember(v, func(key string, index int, value interface{}) {
+			label, token := p.keyLabel(key), key
+			if index >= 0 {
+				label, token = p.indexLabel(index), strconv.Itoa(index)
+			}
+			child := append(path[:len(path):len(path)], token)
+			colon := p.theme.paint(stylePunct, ":")
+			switch {
+			case !isNested(value):
+				p.printf("%s%s%s %s%s\n", indent, label, colon, p.scalar(value), note(child))
+			case isEmpty(value):
+				p.printf("%s%s%s %s%s\n", indent, label, colon, p.theme.paint(stylePunct, emptyText(value)), note(child))
+			default:
+				p.printf("%s%s%s\n", indent, label, colon)
+				walk(value, indent+"  ", child)
+			}
+		})
+	}
+	if isNested(cfg.Doc) {
+		walk(cfg.Doc, "", nil)
+	} else {
+		p.printf("%s%s\n", p.scalar(cfg.Doc), note(nil))
+	}
+	return p.err
+}
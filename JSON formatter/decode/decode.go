@@ -0,0 +1,112 @@
+// Package decode reads the relaxed config formats the viewer accepts,
+// JSONC, JSON5, YAML, TOML and gron assignment lines, into the same value
+// tree package tree builds from plain JSON.
+package decode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// Format names an input format
+type Format string
+
+const (
+	JSON  Format = "json"
+	JSONC Format = "jsonc"
+	JSON5 Format = "json5"
+	YAML  Format = "yaml"
+	TOML  Format = "toml"
+	Gron  Format = "gron"
+)
+
+// extensions maps file extensions to the format they usually hold
+var extensions = map[string]Format{
+	".json":  JSON,
+	".jsonc": JSONC,
+	".json5": JSON5,
+	".yaml":  YAML,
+	".yml":   YAML,
+	".toml":  TOML,
+	".gron":  Gron,
+}
+
+// Formats lists the names ParseFormat accepts
+func Formats() []string {
+	return []string{string(JSON), string(JSONC), string(JSON5), string(YAML), string(TOML), string(Gron)}
+}
+
+// ParseFormat checks a format name given on the command line
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats() {
+		if strings.EqualFold(name, f) {
+			return Format(f), nil
+		}
+	}
+	return "", fmt.Errorf("unknown input format %q, want one of %s", name, strings.Join(Formats(), ", "))
+}
+
+// Detect guesses the format of a file from its extension, JSON when it
+// has none of the known ones
+func Detect(path string) Format {
+	if f, ok := extensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return f
+	}
+	return JSON
+}
+
+// Decode parses data in the given format
+func Decode(format Format, data []byte) (interface{}, error) {
+	switch format {
+	case JSON:
+		return tree.Decode(bytes.NewReader(data))
+	case JSONC:
+		return decodeRelaxed(data, false)
+	case JSON5:
+		return decodeRelaxed(data, true)
+	case YAML:
+		return decodeYAML(data)
+	case TOML:
+		return decodeTOML(data)
+	case Gron:
+		return decodeGron(data)
+	}
+	return nil, fmt.Errorf("unknown input format %q", format)
+}
+
+// SyntaxError is a parse error located in the input
+type SyntaxError struct {
+	Format Format
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s at offset %d", e.Format, e.Msg, e.Offset)
+}
+
+// number keeps a numeric literal as written when it is valid JSON and
+// spells it the canonical way otherwise, never going through float64 so
+// no precision is lost
+func number(lit string) (json.Number, bool) {
+	if validJSONNumber(lit) {
+		return json.Number(lit), true
+	}
+	return tree.NormalizeNumber(lit)
+}
+
+// set adds or replaces a member; later keys win as in encoding/json
+func set(obj tree.Object, key string, v interface{}) tree.Object {
+	for i := range obj {
+		if obj[i].Key == key {
+			obj[i].Value = v
+			return obj
+		}
+	}
+	return append(obj, tree.Member{Key: key, Value: v})
+}
@@ -0,0 +1,180 @@
+package decode
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// maxGronIndex bounds array indexes, which are filled up with nulls
+const maxGronIndex = 1 << 24
+
+// gronStep is one step of an assignment path, an object key or an array
+// index
+type gronStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// decodeGron rebuilds a document from the assignment lines the gron
+// output format writes. Lines may be missing or out of order, as after
+// grep: containers are created on the way to every value and array
+// elements with no line of their own become null.
+func decodeGron(data []byte) (interface{}, error) {
+	var doc interface{}
+	offset := 0
+	for len(data) > 0 {
+		line := data
+		next := len(data)
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, next = data[:i], i+1
+		}
+		if s := strings.TrimSpace(string(line)); s != "" && s != "--" {
+			steps, value, err := parseGronLine(string(line), offset)
+			if err != nil {
+				return nil, err
+			}
+			doc = assign(doc, steps, value)
+		}
+		data = data[next:]
+		offset += next
+	}
+	return doc, nil
+}
+
+// parseGronLine reads `path = value;`, offset being where the line
+// starts in the input
+func parseGronLine(line string, offset int) ([]gronStep, interface{}, error) {
+	fail := func(pos int, msg string) error {
+		return &SyntaxError{Format: Gron, Offset: offset + pos, Msg: msg}
+	}
+	pos := len(line) - len(strings.TrimLeft(line, " \t"))
+	ident := func() string {
+		start := pos
+		for pos < len(line) {
+			r := rune(line[pos])
+			if !(r == '_' || r == '$' || r >= 0x80 || unicode.IsLetter(r) || (pos > start && unicode.IsDigit(r))) {
+				break
+			}
+			pos++
+		}
+		return line[start:pos]
+	}
+
+	if ident() == "" {
+		return nil, nil, fail(pos, "expected a path like json.key")
+	}
+	var steps []gronStep
+	for pos < len(line) && (line[pos] == '.' || line[pos] == '[') {
+		if line[pos] == '.' {
+			pos++
+			key := ident()
+			if key == "" {
+				return nil, nil, fail(pos, "expected a key after '.'")
+			}
+			steps = append(steps, gronStep{key: key})
+			continue
+		}
+		pos++
+		switch {
+		case pos < len(line) && line[pos] == '"':
+			end := stringEnd(line, pos)
+			if end < 0 {
+				return nil, nil, fail(pos, "unterminated key")
+			}
+			var key string
+			if err := json.Unmarshal([]byte(line[pos:end]), &key); err != nil {
+				return nil, nil, fail(pos, "invalid quoted key")
+			}
+			steps = append(steps, gronStep{key: key})
+			pos = end
+		default:
+			start := pos
+			for pos < len(line) && line[pos] >= '0' && line[pos] <= '9' {
+				pos++
+			}
+			i, err := strconv.Atoi(line[start:pos])
+			if err != nil {
+				return nil, nil, fail(start, "expected an array index or a quoted key")
+			}
+			if i > maxGronIndex {
+				return nil, nil, fail(start, "array index too large")
+			}
+			steps = append(steps, gronStep{index: i, isIndex: true})
+		}
+		if pos >= len(line) || line[pos] != ']' {
+			return nil, nil, fail(pos, "expected ']'")
+		}
+		pos++
+	}
+
+	rest := strings.TrimLeft(line[pos:], " \t")
+	pos = len(line) - len(rest)
+	if !strings.HasPrefix(rest, "=") {
+		return nil, nil, fail(pos, "expected '='")
+	}
+	rest = strings.TrimLeft(rest[1:], " \t")
+	pos = len(line) - len(rest)
+	rest = strings.TrimSuffix(strings.TrimRight(rest, " \t\r"), ";")
+	value, err := tree.Decode(strings.NewReader(rest))
+	if err != nil {
+		return nil, nil, fail(pos, "invalid value: "+err.Error())
+	}
+	return steps, value, nil
+}
+
+// stringEnd returns the position after the JSON string starting at
+// start, or -1 when it does not end on the line
+func stringEnd(s string, start int) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// assign stores v at the end of steps below cur and returns the updated
+// cur. Assigning {} or [] to a container of the same kind keeps what it
+// already holds, so the order of the lines does not matter.
+func assign(cur interface{}, steps []gronStep, v interface{}) interface{} {
+	if len(steps) == 0 {
+		switch v := v.(type) {
+		case tree.Object:
+			if obj, ok := cur.(tree.Object); ok && len(v) == 0 {
+				return obj
+			}
+		case []interface{}:
+			if list, ok := cur.([]interface{}); ok && len(v) == 0 {
+				return list
+			}
+		}
+		return v
+	}
+	s := steps[0]
+	if s.isIndex {
+		list, _ := cur.([]interface{})
+		if list == nil {
+			list = []interface{}{}
+		}
+		for len(list) <= s.index {
+			list = append(list, nil)
+		}
+		list[s.index] = assign(list[s.index], steps[1:], v)
+		return list
+	}
+	obj, ok := cur.(tree.Object)
+	if !ok {
+		obj = tree.Object{}
+	}
+	old, _ := obj.Get(s.key)
+	return set(obj, s.key, assign(old, steps[1:], v))
+}
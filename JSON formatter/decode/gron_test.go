@@ -0,0 +1,99 @@
+package decode
+
+import (
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/tree"
+)
+
+// compact writes v as single-line JSON, which keeps member order and
+// number spellings, so equal strings mean equal documents
+func compact(t *testing.T, v interface{}) string {
+	t.Helper()
+	enc, err := encode.New("compact", encode.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := enc.Encode(&sb, v); err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func TestGronRoundTrip(t *testing.T) {
+	docs := []string{
+		`{"shipTo":{"name":"Jane","city":"Pretendville"},"items":[{"sku":"a-1","qty":2}]}`,
+		`{"z":1,"a":2,"m":3}`,
+		`{"empty":{},"none":[],"nested":[[],[{}]]}`,
+		`{"":"empty key","with space":1,"dot.ted":2,"quo\"te":3,"0":4,"ünï":5,"new\nline":6}`,
+		`{"n":[1.50,-0,12345678901234567890,1e-7,2E+3]}`,
+		`{"s":"tab\there \u0001   \\ \" </script>"}`,
+		`{"b":[true,false,null]}`,
+		`[1,"two",{"three":3}]`,
+		`"just a string"`,
+		`42`,
+		`null`,
+	}
+	gron, err := encode.New("gron", encode.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range docs {
+		v, err := tree.Decode(strings.NewReader(doc))
+		if err != nil {
+			t.Fatalf("decoding %s: %v", doc, err)
+		}
+		want := compact(t, v)
+		var sb strings.Builder
+		if err := gron.Encode(&sb, v); err != nil {
+			t.Fatalf("gron of %s: %v", doc, err)
+		}
+		back, err := Decode(Gron, []byte(sb.String()))
+		if err != nil {
+			t.Errorf("reading back the gron of %s: %v\n%s", doc, err, sb.String())
+			continue
+		}
+		if got := compact(t, back); got != want {
+			t.Errorf("round trip of %s gave %s", want, got)
+		}
+	}
+}
+
+// grep leaves some lines out and may reorder them; what is missing on
+// the way to a value is created
+func TestGronSubset(t *testing.T) {
+	tests := []struct {
+		gron, want string
+	}{
+		{`json.items[2].sku = "c-3";`, `{"items":[null,null,{"sku":"c-3"}]}`},
+		{"json.b = 2;\njson.a = 1;", `{"b":2,"a":1}`},
+		{"json.a.b = 1;\njson = {};\njson.a = {};", `{"a":{"b":1}}`},
+		{`json["with space"][0] = true;`, `{"with space":[true]}`},
+	}
+	for _, tt := range tests {
+		v, err := Decode(Gron, []byte(tt.gron))
+		if err != nil {
+			t.Errorf("%q: %v", tt.gron, err)
+			continue
+		}
+		if got := compact(t, v); got != tt.want {
+			t.Errorf("%q gave %s, want %s", tt.gron, got, tt.want)
+		}
+	}
+}
+
+func TestGronErrors(t *testing.T) {
+	for _, src := range []string{
+		`json.a = ;`,
+		`json.a = "unterminated;`,
+		`= 1;`,
+		`json.a[x] = 1;`,
+	} {
+		if v, err := Decode(Gron, []byte(src)); err == nil {
+			t.Errorf("%q decoded to %v, want an error", src, v)
+		}
+	}
+}
@@ -0,0 +1,439 @@
+package decode
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// maxDepth matches the nesting limit of encoding/json
+const maxDepth = 10000
+
+// relaxedParser reads JSONC, JSON with comments and trailing commas, and
+// with json5 set the rest of JSON5: unquoted keys, single quotes, hex
+// numbers, leading or trailing decimal points, explicit plus signs and
+// escaped line breaks in strings
+type relaxedParser struct {
+	data  []byte
+	pos   int
+	depth int
+	json5 bool
+}
+
+func decodeRelaxed(data []byte, json5 bool) (interface{}, error) {
+	p := &relaxedParser{data: data, json5: json5}
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %s after top-level value", p.describe())
+	}
+	return v, nil
+}
+
+func (p *relaxedParser) format() Format {
+	if p.json5 {
+		return JSON5
+	}
+	return JSONC
+}
+
+func (p *relaxedParser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Format: p.format(), Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *relaxedParser) eof() bool { return p.pos >= len(p.data) }
+
+func (p *relaxedParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.data[p.pos]
+}
+
+func (p *relaxedParser) describe() string {
+	if p.eof() {
+		return "end of input"
+	}
+	r, _ := utf8.DecodeRune(p.data[p.pos:])
+	return fmt.Sprintf("%q", r)
+}
+
+// skip moves past whitespace and comments
+func (p *relaxedParser) skip() error {
+	for !p.eof() {
+		r, size := utf8.DecodeRune(p.data[p.pos:])
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+		case p.json5 && (r == '\v' || r == '\f' || r == 0xA0 || r == 0xFEFF || r == 0x2028 || r == 0x2029 || unicode.Is(unicode.Zs, r)):
+		case p.pos == 0 && r == 0xFEFF:
+		case r == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '/':
+			for !p.eof() && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		case r == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '*':
+			end := strings.Index(string(p.data[p.pos+2:]), "*/")
+			if end < 0 {
+				return p.errorf("comment is not closed")
+			}
+			p.pos += end + 4
+			continue
+		default:
+			return nil
+		}
+		p.pos += size
+	}
+	return nil
+}
+
+func (p *relaxedParser) value() (interface{}, error) {
+	if p.eof() {
+		return nil, p.errorf("unexpected end of input, expected a value")
+	}
+	switch c := p.peek(); {
+	case c == '{':
+		return p.nested(p.object)
+	case c == '[':
+		return p.nested(p.array)
+	case c == '"' || (c == '\'' && p.json5):
+		return p.str()
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	}
+
+	start := p.pos
+	word := p.identifier()
+	switch word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	case "Infinity", "NaN":
+		if p.json5 {
+			p.pos = start
+			return nil, p.errorf("%s has no JSON equivalent", word)
+		}
+	}
+	p.pos = start
+	return nil, p.errorf("unexpected %s, expected a value", p.describe())
+}
+
+func (p *relaxedParser) nested(parse func() (interface{}, error)) (interface{}, error) {
+	p.depth++
+	if p.depth > maxDepth {
+		return nil, p.errorf("exceeded max depth")
+	}
+	v, err := parse()
+	p.depth--
+	return v, err
+}
+
+func (p *relaxedParser) object() (interface{}, error) {
+	p.pos++ // '{'
+	obj := tree.Object{}
+	for {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.peek() == '}' {
+			p.pos++
+			return obj, nil
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.peek() != ':' {
+			return nil, p.errorf("unexpected %s, expected ':' after object key", p.describe())
+		}
+		p.pos++
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		obj = set(obj, key, v)
+
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+		default:
+			return nil, p.errorf("unexpected %s, expected ',' or '}'", p.describe())
+		}
+	}
+}
+
+func (p *relaxedParser) key() (string, error) {
+	switch c := p.peek(); {
+	case c == '"' || (c == '\'' && p.json5):
+		return p.str()
+	case p.json5:
+		if key := p.identifier(); key != "" {
+			return key, nil
+		}
+	}
+	return "", p.errorf("unexpected %s, expected an object key", p.describe())
+}
+
+func (p *relaxedParser) array() (interface{}, error) {
+	p.pos++ // '['
+	arr := []interface{}{}
+	for {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("unexpected %s, expected ',' or ']'", p.describe())
+		}
+	}
+}
+
+// identifier reads an ECMAScript identifier name, empty when there is none
+func (p *relaxedParser) identifier() string {
+	start := p.pos
+	for !p.eof() {
+		r, size := utf8.DecodeRune(p.data[p.pos:])
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || (p.pos > start && (unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Pc, r)))) {
+			break
+		}
+		p.pos += size
+	}
+	return string(p.data[start:p.pos])
+}
+
+func (p *relaxedParser) str() (string, error) {
+	start := p.pos
+	quote := p.data[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			p.pos = start
+			return "", p.errorf("string is not terminated")
+		}
+		c := p.data[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\n' || c == '\r':
+			p.pos = start
+			return "", p.errorf("string is not terminated before the end of the line")
+		case c < 0x20 && !p.json5:
+			return "", p.errorf("control character %q in string must be escaped", c)
+		case c == '\\':
+			if err := p.escape(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *relaxedParser) escape(sb *strings.Builder) error {
+	start := p.pos
+	p.pos++ // '\\'
+	if p.eof() {
+		return p.errorf("string is not terminated")
+	}
+	c := p.data[p.pos]
+	p.pos++
+	switch c {
+	case '"', '\\', '/':
+		sb.WriteByte(c)
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'r':
+		sb.WriteByte('\r')
+	case 't':
+		sb.WriteByte('\t')
+	case 'u':
+		r, err := p.hex(4)
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) && strings.HasPrefix(string(p.data[p.pos:]), `\u`) {
+			save := p.pos
+			p.pos += 2
+			if r2, err := p.hex(4); err == nil && utf16.DecodeRune(r, r2) != unicode.ReplacementChar {
+				r = utf16.DecodeRune(r, r2)
+			} else {
+				p.pos = save
+			}
+		}
+		sb.WriteRune(r)
+	default:
+		if !p.json5 {
+			p.pos = start
+			return p.errorf("invalid escape %q in string", "\\"+string(c))
+		}
+		switch c {
+		case '\'':
+			sb.WriteByte('\'')
+		case 'v':
+			sb.WriteByte('\v')
+		case '0':
+			sb.WriteByte(0)
+		case 'x':
+			r, err := p.hex(2)
+			if err != nil {
+				return err
+			}
+			sb.WriteRune(r)
+		case '\r':
+			// an escaped line break continues the string
+			if p.peek() == '\n' {
+				p.pos++
+			}
+		case '\n':
+		default:
+			// any other escaped character stands for itself
+			p.pos--
+			r, size := utf8.DecodeRune(p.data[p.pos:])
+			p.pos += size
+			if r != 0x2028 && r != 0x2029 {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *relaxedParser) hex(n int) (rune, error) {
+	if p.pos+n > len(p.data) {
+		return 0, p.errorf("expected %d hex digits", n)
+	}
+	v, err := strconv.ParseUint(string(p.data[p.pos:p.pos+n]), 16, 32)
+	if err != nil {
+		return 0, p.errorf("expected %d hex digits", n)
+	}
+	p.pos += n
+	return rune(v), nil
+}
+
+func (p *relaxedParser) number() (interface{}, error) {
+	start := p.pos
+	for !p.eof() && strings.IndexByte("+-.0123456789abcdefABCDEFxXInfinityNaN", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+	lit := string(p.data[start:p.pos])
+	p.pos = start
+
+	if !p.json5 {
+		if !validJSONNumber(lit) {
+			return nil, p.errorf("invalid number %q", lit)
+		}
+		p.pos += len(lit)
+		return json.Number(lit), nil
+	}
+
+	sign, body := "", lit
+	if body != "" && (body[0] == '+' || body[0] == '-') {
+		if body[0] == '-' {
+			sign = "-"
+		}
+		body = body[1:]
+	}
+	switch {
+	case body == "Infinity" || body == "NaN":
+		return nil, p.errorf("%s has no JSON equivalent", lit)
+	case strings.HasPrefix(body, "0x") || strings.HasPrefix(body, "0X"):
+		i, ok := new(big.Int).SetString(body[2:], 16)
+		if !ok || strings.HasPrefix(body[2:], "-") || strings.HasPrefix(body[2:], "+") {
+			return nil, p.errorf("invalid hex number %q", lit)
+		}
+		p.pos += len(lit)
+		return json.Number(sign + i.String()), nil
+	}
+	if !validJSON5Decimal(body) {
+		return nil, p.errorf("invalid number %q", lit)
+	}
+	n, ok := number(lit)
+	if !ok {
+		return nil, p.errorf("invalid number %q", lit)
+	}
+	p.pos += len(lit)
+	return n, nil
+}
+
+// validJSONNumber checks the strict JSON number grammar
+func validJSONNumber(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" || s[0] == '.' || (s[0] == '0' && len(s) > 1 && s[1] >= '0' && s[1] <= '9') {
+		return false
+	}
+	mant, _, _ := strings.Cut(strings.ToLower(s), "e")
+	if strings.HasSuffix(mant, ".") {
+		return false
+	}
+	return validJSON5Decimal(s)
+}
+
+// validJSON5Decimal checks an unsigned decimal, which in JSON5 may start
+// or end with its decimal point
+func validJSON5Decimal(s string) bool {
+	mant, exp, hasExp := strings.Cut(strings.ToLower(s), "e")
+	if hasExp {
+		if exp != "" && (exp[0] == '+' || exp[0] == '-') {
+			exp = exp[1:]
+		}
+		if exp == "" || strings.Trim(exp, "0123456789") != "" {
+			return false
+		}
+	}
+	whole, frac, _ := strings.Cut(mant, ".")
+	if whole+frac == "" || strings.Trim(whole+frac, "0123456789") != "" {
+		return false
+	}
+	return len(whole) < 2 || whole[0] != '0'
+}
@@ -0,0 +1,236 @@
+package decode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// tomlDoc builds the value tree from the parser's expressions while
+// remembering which tables the document defined explicitly
+type tomlDoc struct {
+	p    *unstable.Parser
+	root *tomlTable
+}
+
+// tomlTable is a table under construction; members point into it so
+// later [table] headers can reopen it
+type tomlTable struct {
+	keys    []string
+	values  map[string]interface{} // *tomlTable, *tomlArray or a scalar
+	defined bool
+}
+
+// tomlArray is an array of tables made by [[header]]s
+type tomlArray struct {
+	tables []*tomlTable
+}
+
+func newTOMLTable() *tomlTable {
+	return &tomlTable{values: make(map[string]interface{})}
+}
+
+// decodeTOML reads a TOML document keeping key order. Dates and times
+// become strings as written.
+func decodeTOML(data []byte) (interface{}, error) {
+	d := &tomlDoc{p: &unstable.Parser{}, root: newTOMLTable()}
+	d.p.Reset(data)
+	current := d.root
+	for d.p.NextExpression() {
+		expr := d.p.Expression()
+		var err error
+		switch expr.Kind {
+		case unstable.Table:
+			current, err = d.table(expr, false)
+		case unstable.ArrayTable:
+			current, err = d.table(expr, true)
+		case unstable.KeyValue:
+			err = d.keyValue(current, expr)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := d.p.Error(); err != nil {
+		var pe *unstable.ParserError
+		if errors.As(err, &pe) && len(pe.Highlight) > 0 {
+			shape := d.p.Shape(d.p.Range(pe.Highlight))
+			return nil, &SyntaxError{Format: TOML, Offset: shape.Start.Offset, Msg: pe.Message}
+		}
+		return nil, err
+	}
+	return d.root.value(), nil
+}
+
+func (d *tomlDoc) errorf(n *unstable.Node, format string, args ...interface{}) error {
+	return &SyntaxError{Format: TOML, Offset: int(n.Raw.Offset), Msg: fmt.Sprintf(format, args...)}
+}
+
+func keyParts(it unstable.Iterator) []string {
+	var parts []string
+	for it.Next() {
+		parts = append(parts, string(it.Node().Data))
+	}
+	return parts
+}
+
+// table opens the table named by a [header] or appends one for [[header]]
+func (d *tomlDoc) table(expr *unstable.Node, array bool) (*tomlTable, error) {
+	parts := keyParts(expr.Key())
+	t := d.root
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch existing := t.values[part].(type) {
+		case nil:
+			if last && array {
+				next := newTOMLTable()
+				t.set(part, &tomlArray{tables: []*tomlTable{next}})
+				return next, nil
+			}
+			next := newTOMLTable()
+			t.set(part, next)
+			t = next
+		case *tomlTable:
+			if last && (array || existing.defined) {
+				return nil, d.errorf(expr, "table %s is defined twice", strings.Join(parts, "."))
+			}
+			t = existing
+		case *tomlArray:
+			if last && array {
+				next := newTOMLTable()
+				existing.tables = append(existing.tables, next)
+				return next, nil
+			}
+			if last {
+				return nil, d.errorf(expr, "%s is an array of tables", strings.Join(parts, "."))
+			}
+			t = existing.tables[len(existing.tables)-1]
+		default:
+			return nil, d.errorf(expr, "key %s already holds a value", strings.Join(parts[:i+1], "."))
+		}
+	}
+	t.defined = true
+	return t, nil
+}
+
+// keyValue stores key = value, creating the tables of a dotted key
+func (d *tomlDoc) keyValue(t *tomlTable, expr *unstable.Node) error {
+	parts := keyParts(expr.Key())
+	for _, part := range parts[:len(parts)-1] {
+		switch existing := t.values[part].(type) {
+		case nil:
+			next := newTOMLTable()
+			t.set(part, next)
+			t = next
+		case *tomlTable:
+			t = existing
+		default:
+			return d.errorf(expr, "key %s already holds a value", part)
+		}
+	}
+	last := parts[len(parts)-1]
+	if _, dup := t.values[last]; dup {
+		return d.errorf(expr, "key %s is defined twice", strings.Join(parts, "."))
+	}
+	v, err := d.value(expr.Value())
+	if err != nil {
+		return err
+	}
+	t.set(last, v)
+	return nil
+}
+
+func (d *tomlDoc) value(n *unstable.Node) (interface{}, error) {
+	switch n.Kind {
+	case unstable.String:
+		return string(n.Data), nil
+	case unstable.Bool:
+		return string(n.Data) == "true", nil
+	case unstable.Integer:
+		return tomlInteger(string(n.Data))
+	case unstable.Float:
+		return tomlFloat(d, n)
+	case unstable.LocalDate, unstable.LocalTime, unstable.LocalDateTime, unstable.DateTime:
+		return string(n.Data), nil
+	case unstable.Array:
+		arr := []interface{}{}
+		it := n.Children()
+		for it.Next() {
+			v, err := d.value(it.Node())
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case unstable.InlineTable:
+		t := newTOMLTable()
+		it := n.Children()
+		for it.Next() {
+			if err := d.keyValue(t, it.Node()); err != nil {
+				return nil, err
+			}
+		}
+		return t.value(), nil
+	}
+	return nil, d.errorf(n, "unsupported value")
+}
+
+func tomlInteger(lit string) (interface{}, error) {
+	lit = strings.ReplaceAll(lit, "_", "")
+	i, err := strconv.ParseInt(lit, 0, 64)
+	if err != nil {
+		return nil, fmt.Errorf("toml: bad integer %q", lit)
+	}
+	if n, ok := number(lit); ok {
+		return n, nil
+	}
+	// hex, octal and binary integers
+	return json.Number(strconv.FormatInt(i, 10)), nil
+}
+
+func tomlFloat(d *tomlDoc, n *unstable.Node) (interface{}, error) {
+	lit := strings.ReplaceAll(string(n.Data), "_", "")
+	switch strings.TrimLeft(lit, "+-") {
+	case "inf", "nan":
+		return nil, d.errorf(n, "%s has no JSON equivalent", lit)
+	}
+	num, ok := number(lit)
+	if !ok {
+		return nil, d.errorf(n, "bad float %q", lit)
+	}
+	return num, nil
+}
+
+func (t *tomlTable) set(key string, v interface{}) {
+	t.keys = append(t.keys, key)
+	t.values[key] = v
+}
+
+// value converts the finished table into a tree.Object
+func (t *tomlTable) value() tree.Object {
+	obj := make(tree.Object, 0, len(t.keys))
+	for _, k := range t.keys {
+		var v interface{}
+		switch x := t.values[k].(type) {
+		case *tomlTable:
+			v = x.value()
+		case *tomlArray:
+			arr := make([]interface{}, len(x.tables))
+			for i, sub := range x.tables {
+				arr[i] = sub.value()
+			}
+			v = arr
+		default:
+			v = x
+		}
+		obj = append(obj, tree.Member{Key: k, Value: v})
+	}
+	return obj
+}
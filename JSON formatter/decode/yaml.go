@@ -0,0 +1,130 @@
+package decode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// decodeYAML reads a single YAML document keeping mapping order. Anchors
+// and merge keys are resolved, timestamps become strings as written.
+func decodeYAML(data []byte) (interface{}, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		return nil, errors.New("yaml: empty document")
+	}
+	return fromYAML(&doc, 0)
+}
+
+func fromYAML(n *yaml.Node, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("yaml: line %d: exceeded max depth", n.Line)
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return fromYAML(n.Content[0], depth+1)
+	case yaml.AliasNode:
+		return fromYAML(n.Alias, depth+1)
+	case yaml.SequenceNode:
+		arr := make([]interface{}, 0, len(n.Content))
+		for _, item := range n.Content {
+			v, err := fromYAML(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case yaml.MappingNode:
+		return yamlMapping(n, depth)
+	case yaml.ScalarNode:
+		return yamlScalar(n)
+	}
+	return nil, fmt.Errorf("yaml: line %d: unsupported node", n.Line)
+}
+
+func yamlMapping(n *yaml.Node, depth int) (interface{}, error) {
+	obj := tree.Object{}
+	var merged []tree.Object
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if k.Kind == yaml.ScalarNode && k.Tag == "!!merge" {
+			// <<: *base or <<: [*a, *b], explicit keys win
+			sources := []*yaml.Node{v}
+			if v.Kind == yaml.SequenceNode {
+				sources = v.Content
+			}
+			for _, src := range sources {
+				m, err := fromYAML(src, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				mo, ok := m.(tree.Object)
+				if !ok {
+					return nil, fmt.Errorf("yaml: line %d: merge key needs a mapping", v.Line)
+				}
+				merged = append(merged, mo)
+			}
+			continue
+		}
+		if k.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("yaml: line %d: only scalar mapping keys can become JSON keys", k.Line)
+		}
+		val, err := fromYAML(v, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		obj = set(obj, k.Value, val)
+	}
+	for _, mo := range merged {
+		for _, m := range mo {
+			if _, ok := obj.Get(m.Key); !ok {
+				obj = append(obj, m)
+			}
+		}
+	}
+	return obj, nil
+}
+
+func yamlScalar(n *yaml.Node) (interface{}, error) {
+	// yaml.v3 cannot decode numbers beyond float64: it resolves plain ones
+	// as strings and fails on those tagged !!float
+	if n.Tag == "!!float" || n.Tag == "!!str" && n.Style == 0 && validJSONNumber(n.Value) {
+		if num, ok := number(n.Value); ok {
+			return num, nil
+		}
+	}
+	var v interface{}
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	switch x := v.(type) {
+	case int, int64, uint64, float64:
+		// the literal rather than x, which is rounded for large numbers
+		if num, ok := number(strings.ReplaceAll(n.Value, "_", "")); ok {
+			return num, nil
+		}
+		if _, ok := x.(float64); ok {
+			// .inf and .nan, the only floats number rejects
+			return nil, fmt.Errorf("yaml: line %d: %s has no JSON equivalent", n.Line, n.Value)
+		}
+		return json.Number(fmt.Sprint(x)), nil
+	case time.Time:
+		return n.Value, nil
+	case []byte:
+		return n.Value, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,245 @@
+// Package diff compares two decoded documents structurally: key order
+// and formatting do not count, only added, removed and changed values.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"example.com/json-view-formatter/patch"
+	"example.com/json-view-formatter/query"
+	"example.com/json-view-formatter/tree"
+)
+
+// Kind says what happened to a value
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Change is one difference between the documents. Path is in the
+// viewer's query syntax; elements of arrays matched by key show as
+// [key=value].
+type Change struct {
+	Kind Kind
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// Options tunes the comparison
+type Options struct {
+	// KeyField matches the objects of two arrays by this member instead of
+	// by position when every element has a distinct scalar value for it
+	KeyField string
+}
+
+// Result is the outcome of a comparison, as a change list and as a JSON
+// Patch turning the old document into the new one
+type Result struct {
+	Changes []Change
+	Patch   []patch.Op
+}
+
+// Compare returns the differences from a to b
+func Compare(a, b interface{}, opts Options) *Result {
+	d := &differ{opts: opts, res: &Result{}}
+	d.compare(a, b, "$", "")
+	return d.res
+}
+
+type differ struct {
+	opts Options
+	res  *Result
+}
+
+func (d *differ) change(kind Kind, path, ptr string, old, new interface{}) {
+	d.res.Changes = append(d.res.Changes, Change{Kind: kind, Path: path, Old: old, New: new})
+	switch kind {
+	case Added:
+		d.res.Patch = append(d.res.Patch, patch.Op{Op: "add", Path: ptr, Value: new})
+	case Removed:
+		d.res.Patch = append(d.res.Patch, patch.Op{Op: "remove", Path: ptr})
+	case Changed:
+		d.res.Patch = append(d.res.Patch, patch.Op{Op: "replace", Path: ptr, Value: new})
+	}
+}
+
+// compare walks a and b together; path is for people, ptr the JSON
+// Pointer of the same place in the partly patched document
+func (d *differ) compare(a, b interface{}, path, ptr string) {
+	switch x := a.(type) {
+	case tree.Object:
+		if y, ok := b.(tree.Object); ok {
+			d.objects(x, y, path, ptr)
+			return
+		}
+	case []interface{}:
+		if y, ok := b.([]interface{}); ok {
+			if d.opts.KeyField != "" && keyed(x, d.opts.KeyField) && keyed(y, d.opts.KeyField) {
+				d.keyedArrays(x, y, path, ptr)
+			} else {
+				d.arrays(x, y, path, ptr)
+			}
+			return
+		}
+	}
+	if !tree.Equal(a, b) {
+		d.change(Changed, path, ptr, a, b)
+	}
+}
+
+func (d *differ) objects(a, b tree.Object, path, ptr string) {
+	for _, m := range a {
+		p, pp := query.Join(path, m.Key), ptr+tree.Pointer(m.Key)
+		if nv, ok := b.Get(m.Key); ok {
+			d.compare(m.Value, nv, p, pp)
+		} else {
+			d.change(Removed, p, pp, m.Value, nil)
+		}
+	}
+	for _, m := range b {
+		if _, ok := a.Get(m.Key); !ok {
+			d.change(Added, query.Join(path, m.Key), ptr+tree.Pointer(m.Key), nil, m.Value)
+		}
+	}
+}
+
+// arrays compares by position; surplus old elements are removed from the
+// end so the patch indices stay valid
+func (d *differ) arrays(a, b []interface{}, path, ptr string) {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		d.compare(a[i], b[i], indexPath(path, i), ptr+tree.Pointer(strconv.Itoa(i)))
+	}
+	for i := len(a) - 1; i >= n; i-- {
+		d.change(Removed, indexPath(path, i), ptr+tree.Pointer(strconv.Itoa(i)), a[i], nil)
+	}
+	for i := n; i < len(b); i++ {
+		d.change(Added, indexPath(path, i), ptr+tree.Pointer(strconv.Itoa(i)), nil, b[i])
+	}
+}
+
+// keyedArrays matches elements by the key field. The patch removes the
+// elements that are gone, then builds the new order front to back with
+// move and add, patching each matched element once it is in place.
+func (d *differ) keyedArrays(a, b []interface{}, path, ptr string) {
+	field := d.opts.KeyField
+	inB := make(map[string]bool, len(b))
+	for _, item := range b {
+		inB[keyOf(item, field)] = true
+	}
+
+	// working holds the keys of the partly patched array
+	var working []string
+	for i := len(a) - 1; i >= 0; i-- {
+		k := keyOf(a[i], field)
+		if !inB[k] {
+			d.change(Removed, keyPath(path, field, a[i]), ptr+tree.Pointer(strconv.Itoa(i)), a[i], nil)
+		}
+	}
+	old := make(map[string]interface{}, len(a))
+	for _, item := range a {
+		k := keyOf(item, field)
+		old[k] = item
+		if inB[k] {
+			working = append(working, k)
+		}
+	}
+
+	for i, item := range b {
+		k := keyOf(item, field)
+		at := ptr + tree.Pointer(strconv.Itoa(i))
+		prev, ok := old[k]
+		if !ok {
+			d.change(Added, keyPath(path, field, item), at, nil, item)
+			working = insert(working, i, k)
+			continue
+		}
+		if j := indexOf(working, k); j != i {
+			d.res.Patch = append(d.res.Patch, patch.Op{Op: "move", From: ptr + tree.Pointer(strconv.Itoa(j)), Path: at})
+			working = insert(append(working[:j:j], working[j+1:]...), i, k)
+		}
+		d.compare(prev, item, keyPath(path, field, item), at)
+	}
+}
+
+// keyed reports whether every element is an object with a distinct scalar
+// value for field
+func keyed(arr []interface{}, field string) bool {
+	seen := make(map[string]bool, len(arr))
+	for _, item := range arr {
+		obj, ok := item.(tree.Object)
+		if !ok {
+			return false
+		}
+		v, ok := obj.Get(field)
+		if !ok {
+			return false
+		}
+		switch v.(type) {
+		case tree.Object, []interface{}:
+			return false
+		}
+		k := keyOf(item, field)
+		if seen[k] {
+			return false
+		}
+		seen[k] = true
+	}
+	return true
+}
+
+func keyOf(item interface{}, field string) string {
+	v, _ := item.(tree.Object).Get(field)
+	return scalarText(v)
+}
+
+func scalarText(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return strconv.Quote(x)
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case json.Number:
+		// normalized so that 1.0 and 1 key the same element
+		if n, ok := tree.NormalizeNumber(string(x)); ok {
+			return string(n)
+		}
+		return string(x)
+	}
+	return fmt.Sprint(v)
+}
+
+func insert(list []string, i int, s string) []string {
+	list = append(list, "")
+	copy(list[i+1:], list[i:])
+	list[i] = s
+	return list
+}
+
+func indexOf(list []string, s string) int {
+	for i, x := range list {
+		if x == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+func keyPath(path, field string, item interface{}) string {
+	return path + "[" + field + "=" + keyOf(item, field) + "]"
+}
@@ -0,0 +1,66 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/patch"
+	"example.com/json-view-formatter/tree"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	v, err := tree.Decode(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("decoding %s: %v", s, err)
+	}
+	return v
+}
+
+var compareTests = []struct {
+	name string
+	a, b string
+	key  string
+	want []string // kind and path of every change, in order
+}{
+	{"equal", `{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1}`, "", nil},
+	{"numbers by value", `{"n":1.0,"m":100}`, `{"n":1,"m":1e2}`, "", nil},
+	{"large integers stay distinct", `[9007199254740993]`, `[9007199254740992]`, "", []string{"changed $[0]"}},
+	{"members", `{"a":1,"b":2}`, `{"b":3,"c":4}`, "", []string{"removed $.a", "changed $.b", "added $.c"}},
+	{"nested", `{"a":{"b":{"c":1}}}`, `{"a":{"b":{"c":2}}}`, "", []string{"changed $.a.b.c"}},
+	{"type change", `{"a":{"b":1}}`, `{"a":[1]}`, "", []string{"changed $.a"}},
+	{"quoted keys", `{"a b":1}`, `{"a b":2}`, "", []string{`changed $["a b"]`}},
+	{"array grows", `[1,2]`, `[1,2,3]`, "", []string{"added $[2]"}},
+	{"array shrinks", `[1,2,3]`, `[1,2]`, "", []string{"removed $[2]"}},
+	{"arrays by key", `[{"id":1,"v":"a"},{"id":2,"v":"b"}]`, `[{"id":2,"v":"c"},{"id":1,"v":"a"}]`, "id",
+		[]string{"changed $[id=2].v"}},
+	{"keyed removal and addition", `[{"id":1},{"id":2}]`, `[{"id":2},{"id":3}]`, "id",
+		[]string{"removed $[id=1]", "added $[id=3]"}},
+	{"key missing falls back to positions", `[{"id":1},{"v":2}]`, `[{"id":1},{"v":3}]`, "id",
+		[]string{"changed $[1].v"}},
+}
+
+func TestCompare(t *testing.T) {
+	for _, tt := range compareTests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := decode(t, tt.a), decode(t, tt.b)
+			res := Compare(a, b, Options{KeyField: tt.key})
+			var got []string
+			for _, c := range res.Changes {
+				got = append(got, string(c.Kind)+" "+c.Path)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+
+			// the patch turns a into b
+			patched, err := patch.Apply(a, res.Patch)
+			if err != nil {
+				t.Fatalf("applying the patch: %v", err)
+			}
+			if !tree.Equal(patched, b) {
+				t.Errorf("the patch gives %v, want %s", patched, tt.b)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"example.com/json-view-formatter/diff"
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/patch"
+)
+
+// colors of the change markers when output is colored
+var diffColors = map[diff.Kind]string{
+	diff.Added:   "32",
+	diff.Removed: "31",
+	diff.Changed: "33",
+}
+
+var diffMarkers = map[diff.Kind]string{
+	diff.Added:   "+",
+	diff.Removed: "-",
+	diff.Changed: "~",
+}
+
+// runDiff compares two documents structurally and lists the changes by
+// path, or prints them as a JSON Patch. Like diff(1) it exits non-zero
+// when the documents differ.
+func runDiff(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("jsonFormatViewer diff", "old new", stderr)
+	key := flags.String("key", "", "match array elements by this object `field` instead of by index")
+	asPatch := flags.Bool("patch", false, "print the differences as an RFC 6902 JSON Patch")
+	colorMode := flags.String("color", "auto", "color output: auto, always or never; auto honors NO_COLOR")
+	inputName := flags.String("input", "auto", inputUsage)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return exitUsage
+	}
+	input, err := parseInput(*inputName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	color, err := useColor(*colorMode, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	a, code := loadDocument(stderr, stdin, flags.Arg(0), input)
+	if code != exitOK {
+		return code
+	}
+	b, code := loadDocument(stderr, stdin, flags.Arg(1), input)
+	if code != exitOK {
+		return code
+	}
+
+	res := diff.Compare(a, b, diff.Options{KeyField: *key})
+	out := bufio.NewWriter(stdout)
+	if *asPatch {
+		enc, _ := encode.New("json", encode.DefaultOptions)
+		err = enc.Encode(out, patch.Document(res.Patch))
+	} else {
+		for _, c := range res.Changes {
+			fmt.Fprintln(out, formatChange(c, color))
+		}
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		return reportError(stderr, "diff", &writeError{err})
+	}
+	if len(res.Changes) > 0 {
+		return exitDiffers
+	}
+	return exitOK
+}
+
+// formatChange renders one change as "~ path: old -> new"
+func formatChange(c diff.Change, color bool) string {
+	var line string
+	switch c.Kind {
+	case diff.Added:
+		line = fmt.Sprintf("%s %s: %s", diffMarkers[c.Kind], c.Path, compactJSON(c.New))
+	case diff.Removed:
+		line = fmt.Sprintf("%s %s: %s", diffMarkers[c.Kind], c.Path, compactJSON(c.Old))
+	default:
+		line = fmt.Sprintf("%s %s: %s -> %s", diffMarkers[c.Kind], c.Path, compactJSON(c.Old), compactJSON(c.New))
+	}
+	if color {
+		line = "\x1b[" + diffColors[c.Kind] + "m" + line + "\x1b[0m"
+	}
+	return line
+}
+
+// compactJSON formats a value on one line for messages
+func compactJSON(v interface{}) string {
+	var sb strings.Builder
+	enc, _ := encode.New("compact", encode.DefaultOptions)
+	enc.Encode(&sb, v)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	"example.com/json-view-formatter/decode"
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/patch"
+	"example.com/json-view-formatter/query"
+	"example.com/json-view-formatter/tree"
+)
+
+// edit changes a document at the place the pointer tokens name
+type edit func(doc interface{}, tokens []string) (interface{}, error)
+
+const lossyUsage = "edit JSONC, JSON5, YAML and TOML files in place although their comments and layout are lost"
+
+// runSet stores a value at a path, creating missing objects on the way
+func runSet(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("jsonFormatViewer set", "path value [file]", stderr)
+	asString := flags.Bool("string", false, "store the value as a string even when it reads as JSON")
+	lossy := flags.Bool("lossy", false, lossyUsage)
+	inputName := flags.String("input", "auto", inputUsage)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() < 2 || flags.NArg() > 3 {
+		flags.Usage()
+		return exitUsage
+	}
+	value := editValue(flags.Arg(1), *asString)
+	return editFile(stdin, stdout, stderr, *inputName, *lossy, flags.Arg(0), flags.Arg(2), false,
+		func(doc interface{}, tokens []string) (interface{}, error) {
+			var ops []patch.Op
+			for i := 1; i < len(tokens); i++ {
+				if _, err := tree.Resolve(doc, tree.Pointer(tokens[:i]...)); err != nil {
+					ops = append(ops, patch.Op{Op: "add", Path: tree.Pointer(tokens[:i]...), Value: tree.Object{}})
+				}
+			}
+			op := "add"
+			if _, err := tree.Resolve(doc, tree.Pointer(tokens...)); err == nil {
+				op = "replace"
+			}
+			return patch.Apply(doc, append(ops, patch.Op{Op: op, Path: tree.Pointer(tokens...), Value: value}))
+		})
+}
+
+// runDelete removes the value at a path
+func runDelete(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("jsonFormatViewer delete", "path [file]", stderr)
+	lossy := flags.Bool("lossy", false, lossyUsage)
+	inputName := flags.String("input", "auto", inputUsage)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		return exitUsage
+	}
+	return editFile(stdin, stdout, stderr, *inputName, *lossy, flags.Arg(0), flags.Arg(1), true,
+		func(doc interface{}, tokens []string) (interface{}, error) {
+			return patch.Apply(doc, []patch.Op{{Op: "remove", Path: tree.Pointer(tokens...)}})
+		})
+}
+
+// runRename renames the object member at a path, keeping its position
+func runRename(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("jsonFormatViewer rename", "path name [file]", stderr)
+	lossy := flags.Bool("lossy", false, lossyUsage)
+	inputName := flags.String("input", "auto", inputUsage)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() < 2 || flags.NArg() > 3 {
+		flags.Usage()
+		return exitUsage
+	}
+	name := flags.Arg(1)
+	return editFile(stdin, stdout, stderr, *inputName, *lossy, flags.Arg(0), flags.Arg(2), true,
+		func(doc interface{}, tokens []string) (interface{}, error) {
+			return patch.Rename(doc, tree.Pointer(tokens...), name)
+		})
+}
+
+// editValue reads a value from the command line as a JSON literal,
+// falling back to the text itself as a string
+func editValue(arg string, asString bool) interface{} {
+	if asString {
+		return arg
+	}
+	if v, err := tree.Decode(bytes.NewReader([]byte(arg))); err == nil {
+		return v
+	}
+	return arg
+}
+
+// editFile applies fn at the path expr to the document at path, the
+// default config file when it is empty, and writes the result back in
+// its own format: atomically, and for JSON with the file's indentation,
+// so the change is all a diff shows. "-" edits stdin onto stdout. Files
+// whose comments and layout the encoders cannot keep are only edited in
+// place when lossy is set.
+func editFile(stdin io.Reader, stdout, stderr io.Writer, inputName string, lossy bool, expr, path string, mustExist bool, fn edit) int {
+	if path == "" {
+		path = defaultConfigPath
+	}
+	q, err := query.Parse(expr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	input, err := parseInput(inputName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if input == "" {
+		input = decode.Detect(path)
+	}
+	name := displayName(path)
+	if path != stdinName && !lossy && dropsLayout(stderr, name, input) {
+		return exitUsage
+	}
+	data, err := readSource(path, stdin)
+	if err != nil {
+		return reportError(stderr, name, err)
+	}
+	doc, code := decodeData(stderr, name, input, data, false)
+	if code != exitOK {
+		return code
+	}
+
+	tokens, err := q.Tokens(doc)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if _, err := tree.Resolve(doc, tree.Pointer(tokens...)); err != nil && mustExist {
+		fmt.Fprintf(stderr, "path %s matched nothing in %s\n", expr, name)
+		return exitNoMatch
+	}
+	if doc, err = fn(doc, tokens); err != nil {
+		fmt.Fprintf(stderr, "%s: %s: %v\n", name, expr, err)
+		return exitPatchFailed
+	}
+
+	var buf bytes.Buffer
+	if err := editEncoder(input, data).Encode(&buf, doc); err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", name, err)
+		return exitUnsupported
+	}
+	out := buf.Bytes()
+	if isJSON(input) && !bytes.HasSuffix(data, []byte("\n")) {
+		out = bytes.TrimSuffix(out, []byte("\n"))
+	}
+	if path == stdinName {
+		if _, err := stdout.Write(out); err != nil {
+			return reportError(stderr, name, &writeError{err})
+		}
+		return exitOK
+	}
+	if err := writeFileAtomic(path, out); err != nil {
+		return reportError(stderr, name, &writeError{err})
+	}
+	return exitOK
+}
+
+// dropsLayout reports, and explains on stderr, that writing a file of
+// format f back would lose its comments and layout
+func dropsLayout(stderr io.Writer, name string, f decode.Format) bool {
+	switch f {
+	case decode.JSONC, decode.JSON5, decode.YAML, decode.TOML:
+		fmt.Fprintf(stderr, "%s: editing %s in place would drop its comments and layout; use -lossy to edit it anyway\n", name, f)
+		return true
+	}
+	return false
+}
+
+// editEncoder returns the encoder writing an edited document back. JSON
+// and JSONC keep the layout of the original data: on one line when it
+// was, spaced after commas and colons if it was, or indented with the
+// tabs or spaces of its first indented line. Characters beyond ASCII stay
+// escaped when the data wrote them that way.
+func editEncoder(input decode.Format, data []byte) encode.Encoder {
+	format := outputFormat(input)
+	opts := encode.DefaultOptions
+	if isJSON(input) {
+		opts.Spaced, opts.ASCII = jsonStyle(data)
+		body := bytes.TrimSpace(data)
+		if !bytes.Contains(body, []byte("\n")) {
+			format = "compact"
+		}
+		for _, line := range bytes.Split(body, []byte("\n"))[1:] {
+			indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+			if len(indent) > 0 && len(indent) < len(line) {
+				opts.Indent, opts.IndentTabs = len(indent), indent[0] == '\t'
+				break
+			}
+		}
+	}
+	enc, _ := encode.New(format, opts)
+	return enc
+}
+
+// jsonStyle reports whether the first comma or colon of JSON or JSONC
+// data is followed by a space and whether its strings escape the
+// characters beyond ASCII rather than holding them as they are
+func jsonStyle(data []byte) (spaced, ascii bool) {
+	seen, escaped, raw := false, false, false
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '"':
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				switch {
+				case data[i] == '\\' && i+5 < len(data) && data[i+1] == 'u':
+					if n, err := strconv.ParseUint(string(data[i+2:i+6]), 16, 16); err == nil && n >= utf8.RuneSelf {
+						escaped = true
+					}
+					i++
+				case data[i] == '\\':
+					i++
+				case data[i] >= utf8.RuneSelf:
+					raw = true
+				}
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				i = len(data)
+			} else {
+				i += end + 3
+			}
+		case (c == ',' || c == ':') && !seen:
+			seen, spaced = true, i+1 < len(data) && data[i+1] == ' '
+		}
+	}
+	return spaced, escaped && !raw
+}
+
+// isJSON reports whether documents of f are written back as JSON laid out
+// like the original
+func isJSON(f decode.Format) bool {
+	return f == decode.JSON || f == decode.JSONC
+}
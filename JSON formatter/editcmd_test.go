@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// edits keep the layout of the file they change
+func TestEditLayout(t *testing.T) {
+	tests := []struct {
+		name, doc string
+		args      []string
+		want      string
+	}{
+		{"compact", `{"a":[1,2],"b":"x"}`, []string{"set", "c", "1"}, `{"a":[1,2],"b":"x","c":1}`},
+		{"spaced with escapes", `{"a": [1, 2, 3], "b": "\u00e9"}`, []string{"set", "c", "1"}, `{"a": [1, 2, 3], "b": "\u00e9", "c": 1}`},
+		{"raw characters", `{"a": "é"}`, []string{"set", "b", `"ü"`}, `{"a": "é", "b": "ü"}`},
+		{"escapes beyond the BMP", `{"a":"\u00e9"}`, []string{"set", "b", `"😀"`}, `{"a":"\u00e9","b":"\ud83d\ude00"}`},
+		{"spaces in a string", `{"a":", : ","b":1}`, []string{"delete", "b"}, `{"a":", : "}`},
+		{"tabs", "{\n\t\"a\": 1,\n\t\"b\": 2\n}\n", []string{"delete", "a"}, "{\n\t\"b\": 2\n}\n"},
+		{"four spaces", "{\n    \"a\": {\"b\": 1}\n}\n", []string{"rename", "a.b", "c"}, "{\n    \"a\": {\n        \"c\": 1\n    }\n}\n"},
+		{"JSONC comments", `/* c: d */ {"a": 1}`, []string{"set", "-lossy", "-input", "jsonc", "b", "2"}, `{"a": 1, "b": 2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(writeFiles(t, "doc.json", tt.doc), "doc.json")
+			args := append(tt.args, path)
+			if _, errOut, code := runCLI(t, "", args...); code != exitOK {
+				t.Fatalf("exit %d: %s", code, errOut)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
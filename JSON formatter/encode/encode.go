@@ -0,0 +1,226 @@
+// Package encode writes the viewer's value tree in other formats.
+//
+// Values are the ones package tree decodes: nil, bool, float64 or
+// json.Number, string, []interface{} and tree.Object. Plain
+// map[string]interface{} objects are accepted too and written with sorted
+// keys.
+package encode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// Encoder writes one document to w
+type Encoder interface {
+	Encode(w io.Writer, v interface{}) error
+}
+
+// Options holds the settings of the encoders that have any
+type Options struct {
+	// Indent is the indent width of the json format
+	Indent int
+	// IndentTabs indents the json format with tabs instead of spaces
+	IndentTabs bool
+	// Spaced puts a space after the commas and colons of the compact format
+	Spaced bool
+	// ASCII escapes the characters beyond ASCII in the json and compact
+	// formats as \uXXXX
+	ASCII bool
+	// XMLRoot names the document element of the xml format
+	XMLRoot string
+	// XMLArray is the naming rule for array elements in the xml format:
+	// "item" wraps every element in <item>, "singular" uses the parent's
+	// name without its plural ending, "repeat" repeats the parent element
+	// for each entry and anything else is used as the element name.
+	XMLArray string
+	// ColumnWidth caps the width of table and markdown cells, in runes
+	ColumnWidth int
+}
+
+// DefaultOptions are the settings used for zero fields of Options
+var DefaultOptions = Options{Indent: 2, XMLRoot: "root", XMLArray: "item", ColumnWidth: 40}
+
+// registry maps format names to encoder constructors; a new format only
+// needs an entry here
+var registry = map[string]func(Options) Encoder{
+	"json":      func(o Options) Encoder { return jsonEncoder{indent: indent(o), ascii: o.ASCII} },
+	"compact":   func(o Options) Encoder { return jsonEncoder{spaced: o.Spaced, ascii: o.ASCII} },
+	"canonical": func(o Options) Encoder { return jsonEncoder{canonical: true} },
+	"yaml":      func(o Options) Encoder { return yamlEncoder{} },
+	"toml":      func(o Options) Encoder { return tomlEncoder{} },
+	"xml":       func(o Options) Encoder { return xmlEncoder{root: o.XMLRoot, array: o.XMLArray} },
+	"gron":      func(o Options) Encoder { return gronEncoder{} },
+	"table":     func(o Options) Encoder { return tableEncoder{width: o.ColumnWidth} },
+	"csv":       func(o Options) Encoder { return csvEncoder{} },
+	"markdown":  func(o Options) Encoder { return markdownEncoder{width: o.ColumnWidth} },
+}
+
+// New returns the encoder for format
+func New(format string, opts Options) (Encoder, error) {
+	mk, ok := registry[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q, want one of %s", format, strings.Join(Formats(), ", "))
+	}
+	if opts.Indent <= 0 {
+		opts.Indent = DefaultOptions.Indent
+	}
+	if opts.XMLRoot == "" {
+		opts.XMLRoot = DefaultOptions.XMLRoot
+	}
+	if opts.XMLArray == "" {
+		opts.XMLArray = DefaultOptions.XMLArray
+	}
+	if opts.ColumnWidth <= 0 {
+		opts.ColumnWidth = DefaultOptions.ColumnWidth
+	}
+	return finiteEncoder{mk(opts), format}, nil
+}
+
+// finiteEncoder refuses documents holding infinite or NaN floats, which
+// decoders of YAML and similar inputs can produce but no output format
+// writes as a number, before the wrapped encoder writes anything
+type finiteEncoder struct {
+	Encoder
+	format string
+}
+
+func (e finiteEncoder) Encode(w io.Writer, v interface{}) error {
+	err := walkNumbers(v, "$", func(n interface{}, path string) error {
+		if f, ok := n.(float64); ok && (math.IsInf(f, 0) || math.IsNaN(f)) {
+			return &UnrepresentableError{Format: e.format, Path: path, Reason: fmt.Sprintf("%v is not a finite number", f)}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return e.Encoder.Encode(w, v)
+}
+
+// walkNumbers calls fn with every number in v and its path, stopping at
+// the first error
+func walkNumbers(v interface{}, path string, fn func(n interface{}, path string) error) error {
+	if obj, ok := members(v); ok {
+		for _, m := range obj {
+			if err := walkNumbers(m.Value, path+"."+m.Key, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	switch x := v.(type) {
+	case []interface{}:
+		for i, item := range x {
+			if err := walkNumbers(item, fmt.Sprintf("%s[%d]", path, i), fn); err != nil {
+				return err
+			}
+		}
+	case float64, json.Number:
+		return fn(x, path)
+	}
+	return nil
+}
+
+// indent returns one level of json indentation
+func indent(o Options) string {
+	if o.IndentTabs {
+		return strings.Repeat("\t", o.Indent)
+	}
+	return strings.Repeat(" ", o.Indent)
+}
+
+// Formats lists the names New accepts
+func Formats() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writer remembers the first write error so encoders can write freely
+type writer struct {
+	w   io.Writer
+	err error
+}
+
+func (w *writer) str(parts ...string) {
+	for _, s := range parts {
+		if w.err != nil {
+			return
+		}
+		_, w.err = io.WriteString(w.w, s)
+	}
+}
+
+// members returns the members of an object in output order, or false
+// when v is not an object
+func members(v interface{}) (tree.Object, bool) {
+	switch o := v.(type) {
+	case tree.Object:
+		return o, true
+	case map[string]interface{}:
+		keys := make([]string, 0, len(o))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		obj := make(tree.Object, len(keys))
+		for i, k := range keys {
+			obj[i] = tree.Member{Key: k, Value: o[k]}
+		}
+		return obj, true
+	}
+	return nil, false
+}
+
+// formatNumber writes a number: a json.Number exactly as it was written
+// and a float64 the way JavaScript (and RFC 8785) does, plain decimals
+// between 1e-6 and 1e21 and exponent form outside
+func formatNumber(v interface{}) string {
+	switch n := v.(type) {
+	case json.Number:
+		return string(n)
+	case float64:
+		return formatFloat(n)
+	}
+	return fmt.Sprint(v)
+}
+
+func formatFloat(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		// New's encoders reject these before writing anything
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	abs := math.Abs(f)
+	if abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	// Go pads the exponent to two digits, JavaScript does not
+	mant, exp, _ := strings.Cut(s, "e")
+	sign := exp[0]
+	exp = strings.TrimLeft(exp[1:], "0")
+	return mant + "e" + string(sign) + exp
+}
+
+// isNumber reports whether v is one of the tree's number types
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case float64, json.Number:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package encode
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/decode"
+	"example.com/json-view-formatter/tree"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	v, err := tree.Decode(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("decoding %s: %v", s, err)
+	}
+	return v
+}
+
+// encodeString writes v in format with the default options
+func encodeString(t *testing.T, format string, v interface{}) (string, error) {
+	t.Helper()
+	enc, err := New(format, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	err = enc.Encode(&sb, v)
+	return sb.String(), err
+}
+
+// documents every format that reads back can hold; TOML has no null and
+// needs an object at the top
+var roundTripDocs = []string{
+	`{"shipTo":{"name":"Jane","city":"Pretendville"},"items":[{"sku":"a-1","qty":2},{"sku":"b-2","qty":0}]}`,
+	`{"z":1,"a":2,"m":3}`,
+	`{"empty":{},"none":[],"nested":[[1,2],[{"a":[]}]]}`,
+	`{"with space":1,"dot.ted":2,"quo\"te":3,"0":4,"ünï":5,"true":6,"":7}`,
+	`{"n":[1.5,-0.25,9223372036854775807,1e-7,9007199254740993,-7]}`,
+	`{"s":["tab\there","line\nbreak","\u0001","\\ \" ' #","yes","null","1.5","0x10","- a",": b","","  padded  "]}`,
+	`{"b":[true,false]}`,
+}
+
+func TestRoundTrip(t *testing.T) {
+	formats := map[string]decode.Format{
+		"json":    decode.JSON,
+		"compact": decode.JSON,
+		"yaml":    decode.YAML,
+		"toml":    decode.TOML,
+		"gron":    decode.Gron,
+	}
+	for format, input := range formats {
+		for _, doc := range roundTripDocs {
+			v := decodeJSON(t, doc)
+			out, err := encodeString(t, format, v)
+			if err != nil {
+				t.Errorf("%s of %s: %v", format, doc, err)
+				continue
+			}
+			back, err := decode.Decode(input, []byte(out))
+			if err != nil {
+				t.Errorf("reading back the %s of %s: %v\n%s", format, doc, err, out)
+				continue
+			}
+			if !tree.Equal(back, v) {
+				t.Errorf("%s round trip of %s gave %v\n%s", format, doc, back, out)
+			}
+		}
+	}
+}
+
+// values beyond what most formats hold survive where the format allows
+func TestRoundTripExtremes(t *testing.T) {
+	tests := []struct {
+		format string
+		input  decode.Format
+		doc    string
+	}{
+		{"yaml", decode.YAML, `{"big":1e400,"small":[-1e400,1e-400],"s":"1e400","i":123456789012345678901234567890}`},
+		{"yaml", decode.YAML, `[null,"~",{"a":null}]`},
+		{"yaml", decode.YAML, `"just a string"`},
+		{"json", decode.JSON, `{"big":1e400,"s":"\u0000\u001f\u007f"}`},
+		{"gron", decode.Gron, `{"big":1e400,"n":null}`},
+	}
+	for _, tt := range tests {
+		v := decodeJSON(t, tt.doc)
+		out, err := encodeString(t, tt.format, v)
+		if err != nil {
+			t.Errorf("%s of %s: %v", tt.format, tt.doc, err)
+			continue
+		}
+		back, err := decode.Decode(tt.input, []byte(out))
+		if err != nil {
+			t.Errorf("reading back the %s of %s: %v\n%s", tt.format, tt.doc, err, out)
+			continue
+		}
+		if !tree.Equal(back, v) {
+			t.Errorf("%s round trip of %s gave %v\n%s", tt.format, tt.doc, back, out)
+		}
+	}
+}
+
+func TestUnrepresentable(t *testing.T) {
+	tests := []struct {
+		format, doc, path string
+	}{
+		{"toml", `[1]`, "the document"},
+		{"toml", `{"a":{"b":null}}`, "$.a.b"},
+		{"toml", `{"a":[1,99999999999999999999]}`, "$.a[1]"},
+		{"toml", `{"a":1e400}`, "$.a"},
+		{"canonical", `{"a":[1e400]}`, "$.a[0]"},
+		{"xml", `{"a":"bell\u0007"}`, "$.a"},
+		{"xml", `{"a":[{"k\u0000":1}]}`, "$.a[0].k\x00"},
+	}
+	for _, tt := range tests {
+		out, err := encodeString(t, tt.format, decodeJSON(t, tt.doc))
+		var ue *UnrepresentableError
+		if !errors.As(err, &ue) {
+			t.Errorf("%s of %s: got %v, want an UnrepresentableError", tt.format, tt.doc, err)
+			continue
+		}
+		if ue.Path != tt.path {
+			t.Errorf("%s of %s: error at %q, want %q", tt.format, tt.doc, ue.Path, tt.path)
+		}
+		if out != "" {
+			t.Errorf("%s of %s: wrote %q before failing", tt.format, tt.doc, out)
+		}
+	}
+}
+
+// infinities and NaN are refused by every format
+func TestNotFinite(t *testing.T) {
+	for _, f := range []float64{math.Inf(1), math.Inf(-1), math.NaN()} {
+		v := tree.Object{{Key: "a", Value: []interface{}{f}}}
+		for _, format := range Formats() {
+			if out, err := encodeString(t, format, v); err == nil {
+				t.Errorf("%s wrote %v as %q", format, f, out)
+			}
+		}
+	}
+}
+
+func TestXML(t *testing.T) {
+	tests := []struct {
+		array, doc, want string
+		ok               bool
+	}{
+		{"item", `{"a":[1,[2,3],[]]}`, `<root><a><item>1</item><item><item>2</item><item>3</item></item><item/></a></root>`, true},
+		{"singular", `{"entries":["x"],"0 n":null}`, `<root><entries><entry>x</entry></entries><_0_n key="0 n" null="true"/></root>`, true},
+		{"repeat", `{"a":[1,{"b":2}]}`, `<root><a>1</a><a><b>2</b></a></root>`, true},
+		{"repeat", `[1,2]`, `<root><item>1</item><item>2</item></root>`, true},
+		{"repeat", `[]`, `<root/>`, true},
+		{"repeat", `{"a":[]}`, "", false},
+		{"repeat", `{"a":[[1],[2]]}`, "", false},
+		{"repeat", `[[1]]`, "", false},
+		{"item", `{"s":"<&>\"\t\n"}`, "<root><s>&lt;&amp;&gt;&#34;&#x9;&#xA;</s></root>", true},
+	}
+	for _, tt := range tests {
+		enc, err := New("xml", Options{XMLArray: tt.array})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sb strings.Builder
+		err = enc.Encode(&sb, decodeJSON(t, tt.doc))
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("%s %s: wrote %s, want an error", tt.array, tt.doc, sb.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.array, tt.doc, err)
+			continue
+		}
+		// the output is well-formed
+		dec := xml.NewDecoder(strings.NewReader(sb.String()))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("%s %s: %v\n%s", tt.array, tt.doc, err, sb.String())
+				break
+			}
+		}
+		got := strings.TrimPrefix(sb.String(), xml.Header)
+		got = strings.NewReplacer("\n", "", "  ", "").Replace(got)
+		if got != tt.want {
+			t.Errorf("%s %s:\ngot  %s\nwant %s", tt.array, tt.doc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package encode
+
+import (
+	"io"
+	"strconv"
+	"unicode"
+)
+
+// gronEncoder writes one assignment per line, `json.shipTo.city =
+// "Pretendville";`, so documents can be searched with line tools. Objects
+// and arrays get a line of their own assigning {} or [], which keeps
+// empty containers and lets decode rebuild the document from any subset
+// of the lines.
+type gronEncoder struct{}
+
+func (gronEncoder) Encode(w io.Writer, v interface{}) error {
+	gw := &writer{w: w}
+	gronValue(gw, "json", v)
+	return gw.err
+}
+
+func gronValue(w *writer, path string, v interface{}) {
+	if obj, ok := members(v); ok {
+		w.str(path, " = {};\n")
+		for _, m := range obj {
+			gronValue(w, path+gronKey(m.Key), m.Value)
+		}
+		return
+	}
+	switch x := v.(type) {
+	case []interface{}:
+		w.str(path, " = [];\n")
+		for i, item := range x {
+			gronValue(w, path+"["+strconv.Itoa(i)+"]", item)
+		}
+	case nil:
+		w.str(path, " = null;\n")
+	case bool:
+		w.str(path, " = ", strconv.FormatBool(x), ";\n")
+	case string:
+		w.str(path, " = ", quote(x), ";\n")
+	default:
+		w.str(path, " = ", formatNumber(x), ";\n")
+	}
+}
+
+// gronKey is the path step selecting key: .key when key is a JavaScript
+// identifier and ["key"] otherwise
+func gronKey(key string) string {
+	if isIdentifier(key) {
+		return "." + key
+	}
+	return "[" + quote(key) + "]"
+}
+
+// isIdentifier reports whether s can follow a dot in a gron path
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !(r == '_' || r == '$' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package encode
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// jsonEncoder writes JSON: indented when indent is set, on a single line
+// otherwise, with a space after commas and colons when spaced is set.
+// Canonical output follows RFC 8785: no whitespace and members sorted by
+// their UTF-16 code units.
+type jsonEncoder struct {
+	indent    string
+	spaced    bool
+	ascii     bool
+	canonical bool
+}
+
+func (e jsonEncoder) Encode(w io.Writer, v interface{}) error {
+	if e.canonical {
+		// check everything first so a failure leaves no partial output
+		err := walkNumbers(v, "$", func(n interface{}, path string) error {
+			if s, ok := n.(json.Number); ok {
+				if _, err := s.Float64(); err != nil {
+					return &UnrepresentableError{Format: "canonical", Path: path, Reason: "number " + string(s) + " cannot be represented as an IEEE 754 double, as RFC 8785 requires"}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	jw := &writer{w: w}
+	e.value(jw, v, "\n")
+	jw.str("\n")
+	return jw.err
+}
+
+// value writes v; nl is the newline plus indentation of the current line
+func (e jsonEncoder) value(w *writer, v interface{}, nl string) {
+	if obj, ok := members(v); ok {
+		if e.canonical {
+			obj = append(obj[:0:0], obj...)
+			sort.SliceStable(obj, func(i, j int) bool { return utf16Less(obj[i].Key, obj[j].Key) })
+		}
+		if len(obj) == 0 {
+			w.str("{}")
+			return
+		}
+		w.str("{")
+		for i, m := range obj {
+			if i > 0 {
+				e.comma(w)
+			}
+			e.newline(w, nl+e.indent)
+			w.str(e.quote(m.Key), ":")
+			if e.indent != "" || e.spaced {
+				w.str(" ")
+			}
+			e.value(w, m.Value, nl+e.indent)
+		}
+		e.newline(w, nl)
+		w.str("}")
+		return
+	}
+
+	switch x := v.(type) {
+	case []interface{}:
+		if len(x) == 0 {
+			w.str("[]")
+			return
+		}
+		w.str("[")
+		for i, item := range x {
+			if i > 0 {
+				e.comma(w)
+			}
+			e.newline(w, nl+e.indent)
+			e.value(w, item, nl+e.indent)
+		}
+		e.newline(w, nl)
+		w.str("]")
+	case nil:
+		w.str("null")
+	case bool:
+		w.str(strconv.FormatBool(x))
+	case string:
+		w.str(e.quote(x))
+	default:
+		if n, ok := x.(json.Number); ok && e.canonical {
+			// RFC 8785 serializes numbers as IEEE 754 doubles; Encode
+			// rejected the ones out of range
+			f, _ := n.Float64()
+			w.str(formatFloat(f))
+			return
+		}
+		w.str(formatNumber(x))
+	}
+}
+
+func (e jsonEncoder) newline(w *writer, nl string) {
+	if e.indent != "" {
+		w.str(nl)
+	}
+}
+
+func (e jsonEncoder) comma(w *writer) {
+	w.str(",")
+	if e.indent == "" && e.spaced {
+		w.str(" ")
+	}
+}
+
+func (e jsonEncoder) quote(s string) string {
+	if e.ascii {
+		return quoteASCII(s)
+	}
+	return quote(s)
+}
+
+// quote returns s as a JSON string, escaping only what JSON requires
+func quote(s string) string {
+	const hex = "0123456789abcdef"
+	buf := make([]byte, 0, len(s)+2)
+	buf = append(buf, '"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			buf = append(buf, '\\', byte(r))
+		case r == '\n':
+			buf = append(buf, '\\', 'n')
+		case r == '\r':
+			buf = append(buf, '\\', 'r')
+		case r == '\t':
+			buf = append(buf, '\\', 't')
+		case r == '\b':
+			buf = append(buf, '\\', 'b')
+		case r == '\f':
+			buf = append(buf, '\\', 'f')
+		case r < 0x20:
+			buf = append(buf, '\\', 'u', '0', '0', hex[r>>4], hex[r&0xf])
+		default:
+			buf = utf8.AppendRune(buf, r)
+		}
+	}
+	return string(append(buf, '"'))
+}
+
+// quoteASCII is quote with the characters beyond ASCII escaped, those
+// outside the Basic Multilingual Plane as UTF-16 surrogate pairs
+func quoteASCII(s string) string {
+	const hex = "0123456789abcdef"
+	q := quote(s)
+	buf := make([]byte, 0, len(q))
+	for _, r := range q {
+		if r < utf8.RuneSelf {
+			buf = append(buf, byte(r))
+			continue
+		}
+		for _, u := range utf16.Encode([]rune{r}) {
+			buf = append(buf, '\\', 'u', hex[u>>12], hex[u>>8&0xf], hex[u>>4&0xf], hex[u&0xf])
+		}
+	}
+	return string(buf)
+}
+
+func utf16Less(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
@@ -0,0 +1,253 @@
+package encode
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// table is a list of records laid out in columns. Every record is an
+// object whose nested objects are flattened into dotted column names;
+// columns come in the order their keys are first seen.
+type table struct {
+	columns []string
+	rows    []map[string]cell
+	numeric []bool // columns holding nothing but numbers
+}
+
+// cell is the text of one field; null is kept apart so each format can
+// show it its own way, and fields a record lacks have no cell at all
+type cell struct {
+	text   string
+	null   bool
+	number bool
+}
+
+// newTable collects the records of v: the elements of an array, or an
+// object as a single record. Elements that are not objects become a
+// record with a single "value" column.
+func newTable(format string, v interface{}) (*table, error) {
+	var records []interface{}
+	switch x := v.(type) {
+	case []interface{}:
+		records = x
+	default:
+		if _, ok := members(v); !ok {
+			return nil, &UnrepresentableError{Format: format, Path: "the document", Reason: "the top-level value must be an array or an object"}
+		}
+		records = []interface{}{v}
+	}
+
+	t := &table{}
+	index := map[string]int{}
+	for _, rec := range records {
+		row := map[string]cell{}
+		add := func(col string, c cell) {
+			if _, ok := index[col]; !ok {
+				index[col] = len(t.columns)
+				t.columns = append(t.columns, col)
+			}
+			row[col] = c
+		}
+		if _, ok := members(rec); ok {
+			flatten(rec, "", add)
+		} else {
+			add("value", newCell(rec))
+		}
+		t.rows = append(t.rows, row)
+	}
+
+	t.numeric = make([]bool, len(t.columns))
+	for i, col := range t.columns {
+		t.numeric[i] = true
+		for _, row := range t.rows {
+			if c, ok := row[col]; ok && !c.number {
+				t.numeric[i] = false
+				break
+			}
+		}
+	}
+	return t, nil
+}
+
+// flatten adds the leaves of an object under dotted column names. Arrays
+// and empty objects are not flattened but written as compact JSON.
+func flatten(v interface{}, prefix string, add func(string, cell)) {
+	obj, ok := members(v)
+	if !ok || len(obj) == 0 {
+		add(prefix, newCell(v))
+		return
+	}
+	for _, m := range obj {
+		name := m.Key
+		if prefix != "" {
+			name = prefix + "." + m.Key
+		}
+		flatten(m.Value, name, add)
+	}
+}
+
+func newCell(v interface{}) cell {
+	switch x := v.(type) {
+	case nil:
+		return cell{null: true}
+	case bool:
+		return cell{text: strconv.FormatBool(x)}
+	case string:
+		return cell{text: x}
+	case []interface{}:
+		return cell{text: compact(x)}
+	}
+	if _, ok := members(v); ok {
+		return cell{text: compact(v)}
+	}
+	return cell{text: formatNumber(v), number: true}
+}
+
+func compact(v interface{}) string {
+	var sb strings.Builder
+	jsonEncoder{}.value(&writer{w: &sb}, v, "")
+	return sb.String()
+}
+
+// fit shortens s to at most width runes, marking the cut with an
+// ellipsis; control characters are escaped so a cell stays on its line
+func fit(s string, width int) string {
+	if strings.ContainsFunc(s, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		q := strconv.Quote(s)
+		s = q[1 : len(q)-1]
+	}
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:width-1]) + "…"
+}
+
+// tableEncoder writes records as aligned columns for the terminal,
+// numeric columns aligned right
+type tableEncoder struct {
+	width int
+}
+
+func (e tableEncoder) Encode(w io.Writer, v interface{}) error {
+	t, err := newTable("table", v)
+	if err != nil {
+		return err
+	}
+	lines := [][]string{make([]string, len(t.columns)), make([]string, len(t.columns))}
+	widths := make([]int, len(t.columns))
+	for i, col := range t.columns {
+		lines[0][i] = fit(col, e.width)
+	}
+	for _, row := range t.rows {
+		line := make([]string, len(t.columns))
+		for i, col := range t.columns {
+			if c, ok := row[col]; ok {
+				line[i] = c.text
+				if c.null {
+					line[i] = "null"
+				}
+				line[i] = fit(line[i], e.width)
+			}
+		}
+		lines = append(lines, line)
+	}
+	for _, line := range lines {
+		for i, s := range line {
+			widths[i] = max(widths[i], utf8.RuneCountInString(s))
+		}
+	}
+	for i := range t.columns {
+		lines[1][i] = strings.Repeat("-", widths[i])
+	}
+
+	tw := &writer{w: w}
+	for _, line := range lines {
+		var sb strings.Builder
+		for i, s := range line {
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(s))
+			if i > 0 {
+				sb.WriteString("  ")
+			}
+			if t.numeric[i] {
+				sb.WriteString(pad + s)
+			} else {
+				sb.WriteString(s + pad)
+			}
+		}
+		tw.str(strings.TrimRight(sb.String(), " "), "\n")
+	}
+	return tw.err
+}
+
+// csvEncoder writes records as RFC 4180 CSV with a header row. Cells are
+// never shortened; null and missing fields are both empty.
+type csvEncoder struct{}
+
+func (csvEncoder) Encode(w io.Writer, v interface{}) error {
+	t, err := newTable("csv", v)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(t.columns)
+	for _, row := range t.rows {
+		record := make([]string, len(t.columns))
+		for i, col := range t.columns {
+			record[i] = row[col].text
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// markdownEncoder writes records as a GitHub flavored Markdown table
+type markdownEncoder struct {
+	width int
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>")
+
+func (e markdownEncoder) Encode(w io.Writer, v interface{}) error {
+	t, err := newTable("markdown", v)
+	if err != nil {
+		return err
+	}
+	mw := &writer{w: w}
+	line := func(cells []string) {
+		mw.str("|")
+		for _, c := range cells {
+			mw.str(" ", c, " |")
+		}
+		mw.str("\n")
+	}
+	header := make([]string, len(t.columns))
+	rule := make([]string, len(t.columns))
+	for i, col := range t.columns {
+		header[i] = fit(markdownEscaper.Replace(col), e.width)
+		rule[i] = "---"
+		if t.numeric[i] {
+			rule[i] = "--:"
+		}
+	}
+	line(header)
+	line(rule)
+	for _, row := range t.rows {
+		cells := make([]string, len(t.columns))
+		for i, col := range t.columns {
+			if c, ok := row[col]; ok {
+				cells[i] = c.text
+				if c.null {
+					cells[i] = "null"
+				}
+				cells[i] = fit(markdownEscaper.Replace(cells[i]), e.width)
+			}
+		}
+		line(cells)
+	}
+	return mw.err
+}
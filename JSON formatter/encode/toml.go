@@ -0,0 +1,175 @@
+package encode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// tomlEncoder writes TOML. Documents TOML cannot hold, a non-object root,
+// a null anywhere or a number outside TOML's ranges, are rejected with the path of the offending value.
+type tomlEncoder struct{}
+
+// UnrepresentableError reports a value the target format has no way to
+// express
+type UnrepresentableError struct {
+	Format string
+	Path   string
+	Reason string
+}
+
+func (e *UnrepresentableError) Error() string {
+	return fmt.Sprintf("%s: cannot represent %s: %s", e.Format, e.Path, e.Reason)
+}
+
+func (tomlEncoder) Encode(w io.Writer, v interface{}) error {
+	root, ok := members(v)
+	if !ok {
+		return &UnrepresentableError{Format: "toml", Path: "the document", Reason: "the top-level value must be an object"}
+	}
+	// check everything first so a failure leaves no partial output
+	if err := tomlCheck(v, "$"); err != nil {
+		return err
+	}
+	tw := &writer{w: w}
+	tomlTable(tw, root, nil)
+	return tw.err
+}
+
+func tomlCheck(v interface{}, path string) error {
+	if obj, ok := members(v); ok {
+		for _, m := range obj {
+			if err := tomlCheck(m.Value, path+"."+m.Key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	switch x := v.(type) {
+	case nil:
+		return &UnrepresentableError{Format: "toml", Path: path, Reason: "TOML has no null"}
+	case json.Number:
+		// TOML integers are 64-bit and its floats doubles
+		if !strings.ContainsAny(string(x), ".eE") {
+			if _, err := x.Int64(); err != nil {
+				return &UnrepresentableError{Format: "toml", Path: path, Reason: "integer " + string(x) + " is outside the 64-bit range of TOML integers"}
+			}
+		} else if _, err := x.Float64(); err != nil {
+			return &UnrepresentableError{Format: "toml", Path: path, Reason: "number " + string(x) + " is outside the range of TOML floats"}
+		}
+	case []interface{}:
+		for i, item := range x {
+			if err := tomlCheck(item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tomlTable writes the body of the table at path: plain keys first, as
+// TOML requires, then sub-tables, then arrays of tables
+func tomlTable(w *writer, obj tree.Object, path []string) {
+	var tables, arrays tree.Object
+	for _, m := range obj {
+		switch {
+		case isTable(m.Value):
+			tables = append(tables, m)
+		case isTableArray(m.Value):
+			arrays = append(arrays, m)
+		default:
+			w.str(tomlKey(m.Key), " = ", tomlInline(m.Value), "\n")
+		}
+	}
+	for _, m := range tables {
+		sub, _ := members(m.Value)
+		p := append(path[:len(path):len(path)], m.Key)
+		w.str("\n[", tomlPath(p), "]\n")
+		tomlTable(w, sub, p)
+	}
+	for _, m := range arrays {
+		p := append(path[:len(path):len(path)], m.Key)
+		for _, item := range m.Value.([]interface{}) {
+			sub, _ := members(item)
+			w.str("\n[[", tomlPath(p), "]]\n")
+			tomlTable(w, sub, p)
+		}
+	}
+}
+
+func isTable(v interface{}) bool {
+	_, ok := members(v)
+	return ok
+}
+
+// isTableArray reports whether v is a non-empty array of objects only
+func isTableArray(v interface{}) bool {
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) == 0 {
+		return false
+	}
+	for _, item := range arr {
+		if !isTable(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlInline writes v on one line, objects as inline tables
+func tomlInline(v interface{}) string {
+	if obj, ok := members(v); ok {
+		parts := make([]string, len(obj))
+		for i, m := range obj {
+			parts[i] = tomlKey(m.Key) + " = " + tomlInline(m.Value)
+		}
+		if len(parts) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(parts, ", ") + " }"
+	}
+	switch x := v.(type) {
+	case []interface{}:
+		parts := make([]string, len(x))
+		for i, item := range x {
+			parts[i] = tomlInline(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case bool:
+		return strconv.FormatBool(x)
+	case string:
+		return tomlString(x)
+	}
+	return formatNumber(v)
+}
+
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, k := range path {
+		keys[i] = tomlKey(k)
+	}
+	return strings.Join(keys, ".")
+}
+
+// tomlKey leaves bare keys bare and quotes everything else
+func tomlKey(k string) string {
+	if k == "" {
+		return `""`
+	}
+	for _, r := range k {
+		if !(r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return tomlString(k)
+		}
+	}
+	return k
+}
+
+// tomlString quotes s as a basic string, which unlike JSON strings may not
+// hold DEL unescaped
+func tomlString(s string) string {
+	return strings.ReplaceAll(quote(s), "\x7f", `\u007F`)
+}
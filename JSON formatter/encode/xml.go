@@ -0,0 +1,191 @@
+package encode
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// xmlEncoder writes an indented XML document with one element per object
+// member; see Options.XMLArray for how array entries are named. Strings
+// holding characters XML 1.0 forbids, such as most control characters,
+// are rejected, and so are empty and nested arrays in repeat mode, which
+// would vanish or merge with their parent.
+type xmlEncoder struct {
+	root  string
+	array string
+}
+
+func (e xmlEncoder) Encode(w io.Writer, v interface{}) error {
+	// check everything first so a failure leaves no partial output
+	if err := e.check(v, "$"); err != nil {
+		return err
+	}
+	xw := &writer{w: w}
+	xw.str(xml.Header)
+	e.element(xw, e.root, v, "")
+	return xw.err
+}
+
+// element writes v as <name>...</name>, or as one element per entry when
+// v is an array and the naming rule is "repeat"
+func (e xmlEncoder) element(w *writer, name string, v interface{}, indent string) {
+	if arr, ok := v.([]interface{}); ok && e.array == "repeat" && indent != "" {
+		for _, item := range arr {
+			e.element(w, name, item, indent)
+		}
+		return
+	}
+
+	tag, attr := xmlName(name)
+	if v == nil {
+		w.str(indent, "<", tag, attr, ` null="true"/>`, "\n")
+		return
+	}
+
+	children, isObj := members(v)
+	arr, isArr := v.([]interface{})
+	if !isObj && !isArr {
+		w.str(indent, "<", tag, attr, ">", xmlText(v), "</", tag, ">\n")
+		return
+	}
+	if len(children) == 0 && len(arr) == 0 {
+		w.str(indent, "<", tag, attr, "/>\n")
+		return
+	}
+
+	w.str(indent, "<", tag, attr, ">\n")
+	for _, m := range children {
+		e.element(w, m.Key, m.Value, indent+"  ")
+	}
+	itemName := e.itemName(name)
+	for _, item := range arr {
+		e.element(w, itemName, item, indent+"  ")
+	}
+	w.str(indent, "</", tag, ">\n")
+}
+
+// check finds the first value under path the encoder cannot write
+func (e xmlEncoder) check(v interface{}, path string) error {
+	if obj, ok := members(v); ok {
+		for _, m := range obj {
+			p := path + "." + m.Key
+			if !xmlValid(m.Key) {
+				return &UnrepresentableError{Format: "xml", Path: p, Reason: "the key holds a character XML 1.0 does not allow"}
+			}
+			if err := e.check(m.Value, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	switch x := v.(type) {
+	case string:
+		if !xmlValid(x) {
+			return &UnrepresentableError{Format: "xml", Path: path, Reason: "the string holds a character XML 1.0 does not allow"}
+		}
+	case []interface{}:
+		// repeat mode writes every array below the root as a run of
+		// elements, which an empty array does not have and which merges
+		// with the run of an enclosing array
+		if e.array == "repeat" && len(x) == 0 && path != "$" {
+			return &UnrepresentableError{Format: "xml", Path: path, Reason: "an empty array has no elements to repeat"}
+		}
+		for i, item := range x {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if _, nested := item.([]interface{}); nested && e.array == "repeat" {
+				return &UnrepresentableError{Format: "xml", Path: p, Reason: "repeated elements cannot hold an array directly"}
+			}
+			if err := e.check(item, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// xmlValid reports whether s is valid UTF-8 holding only characters of
+// the XML 1.0 Char production
+func xmlValid(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r == '\t', r == '\n', r == '\r':
+		case r < 0x20, r == 0xfffe, r == 0xffff:
+			return false
+		}
+	}
+	return true
+}
+
+// itemName names the entries of an array held in the element parent
+func (e xmlEncoder) itemName(parent string) string {
+	switch e.array {
+	case "item", "repeat":
+		return "item"
+	case "singular":
+		return singular(parent)
+	}
+	return e.array
+}
+
+// singular strips the common English plural endings
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "sses"), strings.HasSuffix(s, "xes"), strings.HasSuffix(s, "ches"), strings.HasSuffix(s, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss") && len(s) > 1:
+		return s[:len(s)-1]
+	}
+	return "item"
+}
+
+// xmlName turns a JSON key into a valid element name. Keys that had to be
+// changed keep their original spelling in a key attribute.
+func xmlName(key string) (tag, attr string) {
+	var sb strings.Builder
+	for i, r := range key {
+		valid := r == '_' || unicode.IsLetter(r) || (i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)))
+		if valid {
+			sb.WriteRune(r)
+		} else {
+			if i == 0 && unicode.IsDigit(r) {
+				sb.WriteRune('_')
+				sb.WriteRune(r)
+				continue
+			}
+			sb.WriteRune('_')
+		}
+	}
+	tag = sb.String()
+	if tag == "" || strings.HasPrefix(strings.ToLower(tag), "xml") {
+		tag = "_" + tag
+	}
+	if tag != key {
+		attr = ` key="` + xmlText(key) + `"`
+	}
+	return tag, attr
+}
+
+func xmlText(v interface{}) string {
+	var s string
+	switch x := v.(type) {
+	case string:
+		s = x
+	case bool:
+		s = strconv.FormatBool(x)
+	default:
+		s = formatNumber(v)
+	}
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
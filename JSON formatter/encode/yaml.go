@@ -0,0 +1,148 @@
+package encode
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// yamlEncoder writes block-style YAML, quoting only the strings a YAML
+// parser would otherwise read as something else
+type yamlEncoder struct{}
+
+func (yamlEncoder) Encode(w io.Writer, v interface{}) error {
+	yw := &writer{w: w}
+	if isContainer(v) && !isEmpty(v) {
+		yamlBlock(yw, v, "")
+	} else {
+		yw.str(yamlScalar(v), "\n")
+	}
+	return yw.err
+}
+
+// yamlBlock writes a non-empty object or array whose lines start at indent
+func yamlBlock(w *writer, v interface{}, indent string) {
+	if obj, ok := members(v); ok {
+		for i, m := range obj {
+			if i > 0 {
+				w.str(indent)
+			}
+			w.str(yamlKey(m.Key), ":")
+			yamlChild(w, m.Value, indent, false)
+		}
+		return
+	}
+	for i, item := range v.([]interface{}) {
+		if i > 0 {
+			w.str(indent)
+		}
+		w.str("-")
+		yamlChild(w, item, indent, true)
+	}
+}
+
+// yamlChild writes the value after "key:" or "-". Nested values in a
+// sequence start on the dash's line, those of a mapping on the next one.
+func yamlChild(w *writer, v interface{}, indent string, inSeq bool) {
+	if !isContainer(v) || isEmpty(v) {
+		w.str(" ", yamlScalar(v), "\n")
+		return
+	}
+	if inSeq {
+		// "- key: v" and "- - v" keep the child on the same line
+		w.str(" ")
+		yamlBlock(w, v, indent+"  ")
+		return
+	}
+	w.str("\n", indent+"  ")
+	yamlBlock(w, v, indent+"  ")
+}
+
+func isContainer(v interface{}) bool {
+	if _, ok := members(v); ok {
+		return true
+	}
+	_, ok := v.([]interface{})
+	return ok
+}
+
+func isEmpty(v interface{}) bool {
+	if obj, ok := members(v); ok {
+		return len(obj) == 0
+	}
+	arr, _ := v.([]interface{})
+	return len(arr) == 0
+}
+
+func yamlScalar(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(x)
+	case string:
+		return yamlString(x)
+	case []interface{}:
+		return "[]"
+	}
+	if _, ok := members(v); ok {
+		return "{}"
+	}
+	if n, ok := v.(json.Number); ok {
+		if _, err := n.Float64(); err != nil {
+			// YAML parsers resolve plain numbers beyond float64 as
+			// strings; the tag keeps them numbers
+			return "!!float " + string(n)
+		}
+	}
+	return formatNumber(v)
+}
+
+func yamlKey(k string) string { return yamlString(k) }
+
+// yamlString leaves s plain when that reads back as the same string
+func yamlString(s string) string {
+	if yamlNeedsQuotes(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// words YAML 1.1 or 1.2 parsers turn into booleans or null
+var yamlReserved = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true,
+	// infinity and not-a-number, in any case and with either sign
+	".inf": true, "-.inf": true, "+.inf": true, ".nan": true,
+}
+
+func yamlNeedsQuotes(s string) bool {
+	if s == "" || yamlReserved[strings.ToLower(s)] {
+		return true
+	}
+	if strings.TrimSpace(s) != s || strings.ContainsAny(s, "\n\r\t\"\\") {
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'%@`") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	// anything that parses as a number would lose its string type, also
+	// numbers beyond float64, which the decoder reads as numbers
+	if _, err := strconv.ParseFloat(s, 64); err == nil || errors.Is(err, strconv.ErrRange) {
+		return true
+	}
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -1,3 +1,11 @@
 module example.com/json-view-formatter
 
 go 1.25.0
+
+require (
+	github.com/pelletier/go-toml/v2 v2.2.2
+	golang.org/x/term v0.34.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.35.0 // indirect
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+)
+
+// htmlOpenRows is about how many rows an HTML report shows before
+// anything is expanded by hand; levels are opened from the top while
+// they fit
+const htmlOpenRows = 400
+
+// writeHTML writes doc as a standalone HTML page: a collapsible tree with
+// a search box and a copy-path button on every row, its style and script
+// inline so the file can be mailed or attached as is. It walks the
+// document with eachMember, in the order prettyPrint prints it.
+func writeHTML(w io.Writer, title string, doc interface{}) error {
+	ew := &errWriter{w: w}
+	ew.printf("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	ew.printf("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	ew.printf("<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", html.EscapeString(title), htmlStyle)
+	ew.printf("<header><h1>%s</h1>", html.EscapeString(title))
+	ew.printf("<input id=\"search\" type=\"search\" placeholder=\"Search keys and values\" autocomplete=\"off\">")
+	ew.printf("<span id=\"count\"></span>")
+	ew.printf("<button type=\"button\" id=\"expand\">Expand all</button><button type=\"button\" id=\"collapse\">Collapse all</button></header>\n")
+	ew.printf("<main id=\"tree\">\n")
+	if isNested(doc) {
+		writeHTMLMembers(ew, doc, "$", 0, openDepth(doc))
+	} else {
+		writeHTMLRow(ew, "", "$", doc)
+	}
+	ew.printf("</main>\n<script>%s</script>\n</body>\n</html>\n", htmlScript)
+	return ew.err
+}
+
+// writeHTMLMembers writes the members of a nested value at depth, the
+// nested ones as <details> that start open above open
+func writeHTMLMembers(ew *errWriter, data interface{}, path string, depth, open int) {
+	eachMember(data, func(key string, index int, value interface{}) {
+		label := "<span class=\"key\">" + html.EscapeString(key) + "</span>"
+		if index >= 0 {
+			label = "<span class=\"index\">[" + strconv.Itoa(index) + "]</span>"
+		}
+		childPath := memberPath(path, key, index)
+		if !isNested(value) || isEmpty(value) {
+			writeHTMLRow(ew, label, childPath, value)
+			return
+		}
+		attr := ""
+		if depth < open {
+			attr = " open"
+		}
+		ew.printf("<details%s><summary>%s <span class=\"size\">%s</span>%s</summary>\n",
+			attr, label, sizeText(value), copyButton(childPath))
+		writeHTMLMembers(ew, value, childPath, depth+1, open)
+		ew.printf("</details>\n")
+	})
+}
+
+// writeHTMLRow writes one scalar or empty container after its label
+// markup; an empty label is a document that is a lone scalar
+func writeHTMLRow(ew *errWriter, label, path string, v interface{}) {
+	ew.printf("<div class=\"row\">")
+	if label != "" {
+		ew.printf("%s<span class=\"punct\">:</span> ", label)
+	}
+	text := "null"
+	switch x := v.(type) {
+	case string:
+		text = x
+	case nil:
+	default:
+		if isEmpty(v) {
+			text = emptyText(v)
+		} else {
+			text = fmt.Sprint(v)
+		}
+	}
+	ew.printf("<span class=\"%s\">%s</span>%s</div>\n", valueType(v), html.EscapeString(text), copyButton(path))
+}
+
+func copyButton(path string) string {
+	return "<button type=\"button\" class=\"copy\" data-path=\"" + html.EscapeString(path) + "\" title=\"Copy path\">copy path</button>"
+}
+
+// sizeText is the summary shown next to a collapsed container
+func sizeText(v interface{}) string {
+	n := 0
+	eachMember(v, func(string, int, interface{}) { n++ })
+	if valueType(v) == "array" {
+		return "[" + strconv.Itoa(n) + "]"
+	}
+	return "{" + strconv.Itoa(n) + "}"
+}
+
+// openDepth picks how many levels start expanded: the most that keep the
+// visible rows within htmlOpenRows, the top level always being shown
+func openDepth(doc interface{}) int {
+	var rows []int // rows[d] counts members at depth d
+	var count func(v interface{}, depth int)
+	count = func(v interface{}, depth int) {
+		eachMember(v, func(_ string, _ int, value interface{}) {
+			if depth == len(rows) {
+				rows = append(rows, 0)
+			}
+			rows[depth]++
+			if isNested(value) {
+				count(value, depth+1)
+			}
+		})
+	}
+	count(doc, 0)
+	if len(rows) == 0 {
+		return 0
+	}
+	shown := rows[0]
+	open := 0
+	for open+1 < len(rows) && shown+rows[open+1] <= htmlOpenRows {
+		open++
+		shown += rows[open]
+	}
+	return open
+}
+
+const htmlStyle = `
+:root { --bg: #fff; --fg: #1f2328; --muted: #656d76; --line: #d0d7de; --hit: #fff8c5;
+  --key: #0550ae; --string: #0a3069; --number: #953800; --boolean: #8250df; --null: #6e7781; }
+@media (prefers-color-scheme: dark) {
+  :root { --bg: #0d1117; --fg: #e6edf3; --muted: #8d96a0; --line: #30363d; --hit: #3d3000;
+    --key: #79c0ff; --string: #a5d6ff; --number: #ffa657; --boolean: #d2a8ff; --null: #8b949e; }
+}
+body { margin: 0; background: var(--bg); color: var(--fg); font: 13px/1.5 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+header { position: sticky; top: 0; display: flex; gap: 8px; align-items: center; padding: 8px 16px;
+  background: var(--bg); border-bottom: 1px solid var(--line); }
+h1 { font-size: 14px; margin: 0 16px 0 0; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+#search { flex: 0 1 320px; font: inherit; padding: 2px 6px; }
+#count { color: var(--muted); min-width: 6em; }
+main { padding: 8px 16px; }
+details > :not(summary) { margin-left: 20px; }
+details > summary { cursor: pointer; }
+.row { margin-left: 14px; white-space: pre-wrap; word-break: break-all; }
+.key { color: var(--key); font-weight: 600; }
+.punct, .index, .size, .object, .array { color: var(--muted); }
+.string { color: var(--string); }
+.number { color: var(--number); }
+.boolean { color: var(--boolean); }
+.null { color: var(--null); font-style: italic; }
+.copy { visibility: hidden; margin-left: 8px; font: 11px sans-serif; padding: 0 4px; cursor: pointer; }
+.row:hover > .copy, summary:hover > .copy { visibility: visible; }
+.hit { background: var(--hit); }
+.current { outline: 2px solid var(--number); }
+`
+
+const htmlScript = `
+(function () {
+  var tree = document.getElementById("tree");
+  var search = document.getElementById("search");
+  var count = document.getElementById("count");
+  var hits = [], current = -1;
+
+  function copy(text, button) {
+    function done() { var t = button.textContent; button.textContent = "copied"; setTimeout(function () { button.textContent = t; }, 1000); }
+    if (navigator.clipboard && window.isSecureContext) {
+      navigator.clipboard.writeText(text).then(done);
+      return;
+    }
+    var area = document.createElement("textarea");
+    area.value = text;
+    document.body.appendChild(area);
+    area.select();
+    document.execCommand("copy");
+    document.body.removeChild(area);
+    done();
+  }
+  tree.addEventListener("click", function (e) {
+    var b = e.target.closest(".copy");
+    if (!b) return;
+    e.preventDefault();
+    copy(b.dataset.path, b);
+  });
+
+  function setAll(open) {
+    tree.querySelectorAll("details").forEach(function (d) { d.open = open; });
+  }
+  document.getElementById("expand").onclick = function () { setAll(true); };
+  document.getElementById("collapse").onclick = function () { setAll(false); };
+
+  function reveal(el) {
+    for (var d = el.parentElement.closest("details"); d; d = d.parentElement.closest("details")) d.open = true;
+  }
+  function focus(i) {
+    if (current >= 0 && hits[current]) hits[current].classList.remove("current");
+    current = i;
+    var el = hits[current];
+    el.classList.add("current");
+    reveal(el);
+    el.scrollIntoView({block: "center"});
+    count.textContent = (current + 1) + " of " + hits.length;
+  }
+  function run() {
+    hits.forEach(function (el) { el.classList.remove("hit", "current"); });
+    hits = [];
+    current = -1;
+    var q = search.value.toLowerCase();
+    if (!q) { count.textContent = ""; return; }
+    tree.querySelectorAll(".key, .string, .number, .boolean, .null").forEach(function (el) {
+      if (el.textContent.toLowerCase().indexOf(q) >= 0) { el.classList.add("hit"); hits.push(el); }
+    });
+    if (hits.length) focus(0); else count.textContent = "no matches";
+  }
+  var timer;
+  search.addEventListener("input", function () { clearTimeout(timer); timer = setTimeout(run, 150); });
+  search.addEventListener("keydown", function (e) {
+    if (e.key !== "Enter" || !hits.length) return;
+    focus((current + (e.shiftKey ? hits.length - 1 : 1)) % hits.length);
+  });
+})();
+`
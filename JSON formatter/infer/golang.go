@@ -0,0 +1,198 @@
+package infer
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in capitals in Go names, as golint wants
+var initialisms = map[string]bool{
+	"api": true, "cpu": true, "css": true, "db": true, "dns": true, "html": true,
+	"http": true, "https": true, "id": true, "ip": true, "json": true, "sql": true,
+	"ssh": true, "tcp": true, "tls": true, "ttl": true, "uid": true, "uri": true,
+	"url": true, "uuid": true, "xml": true,
+}
+
+// Go returns t as gofmt'ed Go type definitions, the root type named name
+// and every object below it a named struct of its own. A package clause
+// is added when pkg is not empty. Numbers too large for int64 or float64
+// are typed json.Number, which holds them as written.
+func (t *Type) Go(name, pkg string) ([]byte, error) {
+	g := &goGen{names: map[string]bool{}}
+	root := goName(name)
+	if t.merged() == kindObject {
+		g.declare(t, root, "")
+	} else {
+		g.names[root] = true
+		g.decls = append(g.decls, "")
+		g.decls[0] = fmt.Sprintf("type %s %s\n", root, g.typeExpr(t, root, root))
+	}
+
+	var sb strings.Builder
+	if pkg != "" {
+		fmt.Fprintf(&sb, "package %s\n\n", pkg)
+	}
+	if g.jsonNumber {
+		sb.WriteString("import \"encoding/json\"\n\n")
+	}
+	sb.WriteString(strings.Join(g.decls, "\n"))
+	src, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return nil, fmt.Errorf("generated Go does not parse: %v", err)
+	}
+	return src, nil
+}
+
+// goGen collects the type declarations of one Go call
+type goGen struct {
+	names      map[string]bool
+	decls      []string
+	jsonNumber bool // a type uses json.Number
+}
+
+// typeExpr returns the Go type for t; hint names a struct it needs and
+// parent prefixes that name when it is taken
+func (g *goGen) typeExpr(t *Type, hint, parent string) string {
+	var expr string
+	switch t.merged() {
+	case 0:
+		return "any"
+	case kindBool:
+		expr = "bool"
+	case kindInteger, kindNumber:
+		switch {
+		case t.wide:
+			expr = "json.Number"
+			g.jsonNumber = true
+		case t.merged() == kindInteger:
+			expr = "int64"
+		default:
+			expr = "float64"
+		}
+	case kindString:
+		expr = "string"
+	case kindObject:
+		if len(t.fields) == 0 {
+			return "map[string]any"
+		}
+		expr = g.declare(t, hint, parent)
+	case kindArray:
+		elem := "any"
+		if t.elem != nil {
+			elem = g.typeExpr(t.elem, singular(hint), parent)
+		}
+		return "[]" + elem
+	default:
+		// values of several kinds share no Go type
+		return "any"
+	}
+	if t.nullable() {
+		return "*" + expr
+	}
+	return expr
+}
+
+// declare adds a struct declaration for t and returns its name
+func (g *goGen) declare(t *Type, hint, parent string) string {
+	name := goName(hint)
+	if g.names[name] {
+		name = parent + name
+	}
+	for i := 2; g.names[name]; i++ {
+		name = goName(hint) + strconv.Itoa(i)
+	}
+	g.names[name] = true
+	// reserve the slot so the outer type comes before the ones it uses
+	slot := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "type %s struct {\n", name)
+	used := map[string]bool{}
+	for _, f := range t.fields {
+		field := goName(f.name)
+		for i := 2; used[field]; i++ {
+			field = goName(f.name) + strconv.Itoa(i)
+		}
+		used[field] = true
+
+		expr := g.typeExpr(f.typ, f.name, name)
+		tag := f.name
+		if tag == "-" {
+			// a bare "-" tells encoding/json to skip the field
+			tag = "-,"
+		}
+		if t.optional(f) {
+			tag += ",omitempty"
+			// omitempty leaves structs alone, a pointer can be left out
+			if f.typ.merged() == kindObject && !strings.HasPrefix(expr, "*") && !strings.HasPrefix(expr, "map[") {
+				expr = "*" + expr
+			}
+		}
+		fmt.Fprintf(&sb, "\t%s %s `json:%s`\n", field, expr, strconv.Quote(tag))
+	}
+	sb.WriteString("}\n")
+	g.decls[slot] = sb.String()
+	return name
+}
+
+// goName turns a JSON key into an exported Go identifier: user_id and
+// userId both become UserID
+func goName(key string) string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+	}
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(cur) > 0 && (unicode.IsLower(cur[len(cur)-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]))):
+			flush()
+		}
+		cur = append(cur, r)
+	}
+	flush()
+
+	var sb strings.Builder
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			sb.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		sb.WriteRune(unicode.ToUpper(r[0]))
+		sb.WriteString(string(r[1:]))
+	}
+	name := sb.String()
+	if name == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "F" + name
+	}
+	return name
+}
+
+// singular guesses the element name of a plural array name
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ss"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name + "Item"
+}
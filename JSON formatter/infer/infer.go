@@ -0,0 +1,131 @@
+// Package infer derives a type description from sample documents: the
+// kinds each value takes, which object members are optional or nullable
+// and what arrays hold. The result can be written as a JSON Schema or as
+// Go type definitions.
+package infer
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// kind is a set of JSON types
+type kind uint8
+
+const (
+	kindNull kind = 1 << iota
+	kindBool
+	kindInteger
+	kindNumber
+	kindString
+	kindObject
+	kindArray
+)
+
+// Type is what the values seen at one place of the samples have in
+// common. The zero Type has seen nothing.
+type Type struct {
+	kinds   kind
+	objects int // objects merged in, to tell optional fields
+	fields  []*member
+	index   map[string]*member
+	elem    *Type // array elements, nil until one is seen
+	wide    bool  // numbers beyond int64 or float64 were seen
+}
+
+// member is an object member and the type of its values
+type member struct {
+	name string
+	typ  *Type
+	seen int
+}
+
+// Infer merges the samples into one type
+func Infer(samples ...interface{}) *Type {
+	t := &Type{}
+	for _, s := range samples {
+		t.Add(s)
+	}
+	return t
+}
+
+// Add merges one more value into t
+func (t *Type) Add(v interface{}) {
+	switch x := v.(type) {
+	case nil:
+		t.kinds |= kindNull
+	case bool:
+		t.kinds |= kindBool
+	case json.Number:
+		if strings.ContainsAny(string(x), ".eE") {
+			t.kinds |= kindNumber
+			_, err := x.Float64()
+			t.wide = t.wide || err != nil
+		} else {
+			t.kinds |= kindInteger
+			_, err := x.Int64()
+			t.wide = t.wide || err != nil
+		}
+	case float64:
+		if x == math.Trunc(x) {
+			t.kinds |= kindInteger
+			t.wide = t.wide || x < math.MinInt64 || x >= math.MaxInt64
+		} else {
+			t.kinds |= kindNumber
+		}
+	case string:
+		t.kinds |= kindString
+	case tree.Object:
+		t.kinds |= kindObject
+		t.objects++
+		for _, m := range x {
+			f := t.field(m.Key)
+			f.seen++
+			f.typ.Add(m.Value)
+		}
+	case []interface{}:
+		t.kinds |= kindArray
+		for _, e := range x {
+			if t.elem == nil {
+				t.elem = &Type{}
+			}
+			t.elem.Add(e)
+		}
+	}
+}
+
+func (t *Type) field(name string) *member {
+	if f, ok := t.index[name]; ok {
+		return f
+	}
+	if t.index == nil {
+		t.index = map[string]*member{}
+	}
+	f := &member{name: name, typ: &Type{}}
+	t.index[name] = f
+	t.fields = append(t.fields, f)
+	return f
+}
+
+// optional reports whether some objects lacked the member
+func (t *Type) optional(f *member) bool {
+	return f.seen < t.objects
+}
+
+// nullable reports whether null was among the values
+func (t *Type) nullable() bool {
+	return t.kinds&kindNull != 0
+}
+
+// merged returns the kinds without null, with integers folded into
+// numbers when both were seen: the most specific type all values share
+func (t *Type) merged() kind {
+	k := t.kinds &^ kindNull
+	if k&kindNumber != 0 {
+		k &^= kindInteger
+	}
+	return k
+}
@@ -0,0 +1,114 @@
+package infer
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/tree"
+)
+
+func infer(t *testing.T, samples ...string) *Type {
+	t.Helper()
+	typ := &Type{}
+	for _, s := range samples {
+		v, err := tree.Decode(strings.NewReader(s))
+		if err != nil {
+			t.Fatalf("decoding %s: %v", s, err)
+		}
+		typ.Add(v)
+	}
+	return typ
+}
+
+var schemaTests = []struct {
+	name    string
+	samples []string
+	want    string // the schema without $schema
+}{
+	{"scalar", []string{`"a"`}, `{"type":"string"}`},
+	{"integer and number", []string{`1`, `1.5`}, `{"type":"number"}`},
+	{"nullable", []string{`1`, `null`}, `{"type":["integer","null"]}`},
+	{"mixed", []string{`1`, `"a"`, `true`}, `{"type":["string","integer","boolean"]}`},
+	{"optional member", []string{`{"a":1,"b":2}`, `{"a":3}`},
+		`{"type":"object","properties":{"a":{"type":"integer"},"b":{"type":"integer"}},"required":["a"]}`},
+	{"array elements", []string{`[1,null]`, `[]`}, `{"type":"array","items":{"type":["integer","null"]}}`},
+	{"empty array", []string{`[]`}, `{"type":"array"}`},
+	{"nothing in common", []string{`{}`, `[]`}, `{"type":["object","array"],"properties":{}}`},
+}
+
+func TestSchema(t *testing.T) {
+	enc, err := encode.New("compact", encode.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range schemaTests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := infer(t, tt.samples...).Schema()
+			if s[0].Key != "$schema" || s[0].Value != draft {
+				t.Errorf("schema starts with %v", s[0])
+			}
+			var sb strings.Builder
+			if err := enc.Encode(&sb, s[1:]); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(sb.String()); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+var goTests = []struct {
+	name    string
+	samples []string
+	want    []string // lines the output holds
+}{
+	{"fields", []string{`{"user_id":1,"name":"a","score":1.5,"ok":true,"tags":["x"]}`},
+		[]string{"type Root struct {", "UserID int64 `json:\"user_id\"`", "Name string", "Score float64", "Ok bool", "Tags []string"}},
+	{"optional and nullable", []string{`{"a":1,"b":{"c":1}}`, `{"a":null}`},
+		[]string{"A *int64 `json:\"a\"`", "B *B `json:\"b,omitempty\"`", "type B struct {"}},
+	{"integers beyond int64", []string{`{"id":123456789012345678901234567890,"n":9223372036854775807}`},
+		[]string{`import "encoding/json"`, "ID json.Number", "N int64"}},
+	{"numbers beyond float64", []string{`{"f":[1e400,2.5]}`},
+		[]string{"F []json.Number"}},
+	{"mixed kinds", []string{`{"a":1}`, `{"a":"x"}`}, []string{"A any"}},
+	{"empty object", []string{`{"a":{}}`}, []string{"A map[string]any"}},
+	{"keys encoding/json treats specially", []string{`{"-":1,"a-b":2,"1st":3}`},
+		[]string{"Field int64 `json:\"-,\"`", "AB int64", "F1st int64"}},
+	{"name clashes", []string{`{"a":{"x":1},"b":{"a":{"y":1}}}`}, []string{"type A struct {", "type BA struct {"}},
+	{"array root", []string{`[{"a":1}]`}, []string{"type Root []RootItem", "type RootItem struct {"}},
+}
+
+func TestGo(t *testing.T) {
+	for _, tt := range goTests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := infer(t, tt.samples...).Go("root", "p")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := parser.ParseFile(token.NewFileSet(), "", src, 0); err != nil {
+				t.Fatalf("the output does not parse: %v\n%s", err, src)
+			}
+			// gofmt aligns fields, so compare with runs of spaces folded
+			got := strings.Join(strings.Fields(string(src)), " ")
+			for _, line := range tt.want {
+				if !strings.Contains(got, strings.Join(strings.Fields(line), " ")) {
+					t.Errorf("no %q in\n%s", line, src)
+				}
+			}
+		})
+	}
+}
+
+func TestGoWithoutPackage(t *testing.T) {
+	src, err := infer(t, `{"n":1e400}`).Go("doc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(src), "import \"encoding/json\"\n\ntype Doc struct {") {
+		t.Errorf("got\n%s", src)
+	}
+}
@@ -0,0 +1,65 @@
+package infer
+
+import "example.com/json-view-formatter/tree"
+
+// draft is the JSON Schema dialect Schema writes
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// kindNames are the JSON Schema type names in the order they are listed
+var kindNames = []struct {
+	k    kind
+	name string
+}{
+	{kindObject, "object"},
+	{kindArray, "array"},
+	{kindString, "string"},
+	{kindInteger, "integer"},
+	{kindNumber, "number"},
+	{kindBool, "boolean"},
+	{kindNull, "null"},
+}
+
+// Schema returns t as a JSON Schema document. Members present in every
+// sample are required and nullable values list "null" among their types.
+func (t *Type) Schema() tree.Object {
+	return append(tree.Object{{Key: "$schema", Value: draft}}, t.schema()...)
+}
+
+func (t *Type) schema() tree.Object {
+	var s tree.Object
+	k := t.merged() | t.kinds&kindNull
+	var types []interface{}
+	for _, kn := range kindNames {
+		if k&kn.k != 0 {
+			types = append(types, kn.name)
+		}
+	}
+	switch len(types) {
+	case 0:
+		// nothing seen, such as the elements of an empty array
+		return s
+	case 1:
+		s = append(s, tree.Member{Key: "type", Value: types[0]})
+	default:
+		s = append(s, tree.Member{Key: "type", Value: types})
+	}
+
+	if k&kindObject != 0 {
+		props := tree.Object{}
+		required := []interface{}{}
+		for _, f := range t.fields {
+			props = append(props, tree.Member{Key: f.name, Value: f.typ.schema()})
+			if !t.optional(f) {
+				required = append(required, f.name)
+			}
+		}
+		s = append(s, tree.Member{Key: "properties", Value: props})
+		if len(required) > 0 {
+			s = append(s, tree.Member{Key: "required", Value: required})
+		}
+	}
+	if k&kindArray != 0 && t.elem != nil {
+		s = append(s, tree.Member{Key: "items", Value: t.elem.schema()})
+	}
+	return s
+}
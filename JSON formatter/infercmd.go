@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/infer"
+)
+
+// runInfer derives a JSON Schema or Go types from sample documents.
+// Every sample adds to the same type, so members missing from some are
+// optional and values that are null in some are nullable.
+func runInfer(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("jsonFormatViewer infer", "sample...", stderr)
+	format := flags.String("o", "schema", "output `format`: schema (JSON Schema) or go")
+	typeName := flags.String("type", "", "name of the root Go type, by default taken from the first sample's file name")
+	pkg := flags.String("package", "", "add a package clause to the Go output")
+	inputName := flags.String("input", "auto", inputUsage)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	if *format != "schema" && *format != "go" {
+		fmt.Fprintf(stderr, "bad -o %q, want schema or go\n", *format)
+		return exitUsage
+	}
+	input, err := parseInput(*inputName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	paths, err := expandArgs(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	t := &infer.Type{}
+	for _, path := range paths {
+		doc, code := loadDocument(stderr, stdin, path, input)
+		if code != exitOK {
+			return code
+		}
+		t.Add(doc)
+	}
+
+	out := bufio.NewWriter(stdout)
+	if *format == "schema" {
+		enc, _ := encode.New("json", encode.DefaultOptions)
+		err = enc.Encode(out, t.Schema())
+	} else {
+		name := *typeName
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(paths[0]), filepath.Ext(paths[0]))
+			if paths[0] == stdinName {
+				name = "Root"
+			}
+		}
+		var src []byte
+		if src, err = t.Go(name, *pkg); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		_, err = out.Write(src)
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		return reportError(stderr, "infer", &writeError{err})
+	}
+	return exitOK
+}
@@ -3,65 +3,177 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"reflect"
+	"sort"
+	"strconv"
+
+	"example.com/json-view-formatter/tree"
 )
 
-func prettyPrint(data interface{}, indent string) {
+// errWriter remembers the first write error so the recursive printer
+// does not have to check the result of every Fprintf
+type errWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(ew.w, format, args...)
+	ew.n += int64(n)
+	ew.err = err
+}
+
+// printer is one rendering pass: the output with its first error and
+// the theme decorating it, nil for plain text
+type printer struct {
+	errWriter
+	theme *theme
+	// gutter, when set, returns what starts the line showing the value at
+	// path in place of its indentation; -watch marks changes with it
+	gutter func(path, indent string) string
+}
+
+// prettyPrint writes data to w as an indented tree, colored with th when
+// it is not nil, and returns the first write error, if any
+func prettyPrint(w io.Writer, data interface{}, indent string, th *theme) error {
+	p := &printer{errWriter: errWriter{w: w}, theme: th}
+	printValue(p, data, indent, "$")
+	return p.err
+}
+
+// printValue prints data, which is at path in the document, as a tree
+func printValue(p *printer, data interface{}, indent, path string) {
+	if !isNested(data) || isEmpty(data) {
+		p.printf("%s%s\n", p.lead(path, indent), p.scalar(data))
+		return
+	}
+	eachMember(data, func(key string, index int, value interface{}) {
+		label := p.keyLabel(key)
+		if index >= 0 {
+			label = p.indexLabel(index)
+		}
+		var childPath string
+		if p.gutter != nil {
+			childPath = memberPath(path, key, index)
+		}
+		p.member(indent, childPath, label, value)
+	})
+}
+
+// lead is what starts the line of the value at path: the indentation,
+// or the gutter's replacement for it
+func (p *printer) lead(path, indent string) string {
+	if p.gutter == nil {
+		return indent
+	}
+	return p.gutter(path, indent)
+}
+
+// eachMember calls fn for the members of a nested value in the order the
+// tree view prints them, with index -1 for object members and the key ""
+// for array elements. Everything that walks a document the way it is
+// displayed goes through here.
+func eachMember(data interface{}, fn func(key string, index int, value interface{})) {
+	// objects from tree.Decode keep their members in source order, so
+	// scalars and nested values are printed interleaved as in the file
+	if obj, ok := data.(tree.Object); ok {
+		for _, m := range obj {
+			fn(m.Key, -1, m.Value)
+		}
+		return
+	}
+
 	d := reflect.ValueOf(data)
 
 	switch d.Kind() {
 	case reflect.Map:
-		// map to store data with nested map/slice
-		nestedValues := make(map[string]interface{})
-		// print all items without any nested data
-		for _, key := range d.MapKeys() {
-			value := d.MapIndex(key).Interface()
-			if (reflect.ValueOf(value).Kind() == reflect.Map) || (reflect.ValueOf(value).Kind() == reflect.Slice) {
-				nestedValues[key.Interface().(string)] = value
-			}else {
-				fmt.Printf("%s%s: %v\n", indent, key.Interface(), d.MapIndex(key).Interface())
-			}
-        }
-		// print nested data using recursion
-		for key, val := range nestedValues {
-			fmt.Printf("%s%s: \n", indent, key)
-			prettyPrint(val, indent+"  ")
+		// plain maps have no order of their own; their keys are sorted,
+		// as the encoders write them, so every run prints the same
+		keys := d.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			fn(key.String(), -1, d.MapIndex(key).Interface())
 		}
 	case reflect.Slice:
-		// if the data is in a slice itterate the slice and recursively print it
+		// if the data is in a slice itterate the slice and print every
+		// element under its index
 		for i := 0; i < d.Len(); i++ {
-			prettyPrint(d.Index(i).Interface(), indent+"  ")
-        }
-	default:
-		fmt.Printf("%s%v\n", indent, d)
+			fn("", i, d.Index(i).Interface())
+		}
 	}
 }
 
-func main() {
-	
-	configPath := "cfg\\config.json"
+// member prints one labelled value, on the same line when it is a scalar
+// and as an indented subtree otherwise
+func (p *printer) member(indent, path, label string, value interface{}) {
+	if isNested(value) && !isEmpty(value) {
+		p.printf("%s%s%s\n", p.lead(path, indent), label, p.theme.paint(stylePunct, ":"))
+		printValue(p, value, indent+"  ", path)
+		return
+	}
+	p.printf("%s%s%s %s\n", p.lead(path, indent), label, p.theme.paint(stylePunct, ":"), p.scalar(value))
+}
+
+func (p *printer) keyLabel(key string) string {
+	return p.theme.paint(styleKey, key)
+}
 
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		log.Fatalf("Error reading file %s: %v", configPath, err)
+func (p *printer) indexLabel(i int) string {
+	return p.theme.paint(stylePunct, "["+strconv.Itoa(i)+"]")
+}
+
+// scalar formats a leaf value, JSON null as null and empty objects and
+// arrays as {} and []
+func (p *printer) scalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return p.theme.paint(styleNull, "null")
+	case string:
+		return p.theme.paint(styleString, v)
+	case bool:
+		return p.theme.paint(styleBool, strconv.FormatBool(v))
+	case float64, json.Number:
+		return p.theme.paint(styleNumber, fmt.Sprint(v))
 	}
+	if isEmpty(v) {
+		return p.theme.paint(stylePunct, emptyText(v))
+	}
+	return fmt.Sprint(v)
+}
 
-	// validate if the json
-	isJsonValid := json.Valid(data)
+// isNested reports whether value is printed as a subtree
+func isNested(value interface{}) bool {
+	kind := reflect.ValueOf(value).Kind()
+	return kind == reflect.Map || kind == reflect.Slice
+}
 
-	if !isJsonValid {
-		log.Fatalf("invalid JSON file  - %s", configPath)
+// isEmpty reports whether value is an object or array with no members
+func isEmpty(v interface{}) bool {
+	switch x := v.(type) {
+	case tree.Object:
+		return len(x) == 0
+	case []interface{}:
+		return len(x) == 0
+	case map[string]interface{}:
+		return len(x) == 0
 	}
+	return false
+}
 
-	// Parse into interface{}
-	var result interface{}
-	if err := json.Unmarshal(data, &result); err != nil {
-		log.Fatalf("Error parsing JSON: %v", err)
+// emptyText is how an empty container is shown
+func emptyText(v interface{}) string {
+	if _, ok := v.([]interface{}); ok {
+		return "[]"
 	}
+	return "{}"
+}
 
-	// Print formatted output
-	fmt.Println("=== JSON Configuration ===")
-	prettyPrint(result, "  ")
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/tree"
+)
+
+func TestPrettyPrint(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"source order", tree.Object{{Key: "z", Value: json.Number("1")}, {Key: "a", Value: []interface{}{"x", nil}}, {Key: "m", Value: true}},
+			"z: 1\na:\n  [0]: x\n  [1]: null\nm: true\n"},
+		{"maps by sorted key", map[string]interface{}{"z": 1.5, "b": map[string]interface{}{"y": "2", "x": []interface{}{}}, "a": "1"},
+			"a: 1\nb:\n  x: []\n  y: 2\nz: 1.5\n"},
+		{"empty containers", tree.Object{{Key: "o", Value: tree.Object{}}, {Key: "m", Value: map[string]interface{}{}}, {Key: "l", Value: []interface{}{}}},
+			"o: {}\nm: {}\nl: []\n"},
+		{"scalar root", "text", "text\n"},
+	}
+	for _, tt := range tests {
+		// maps are printed the same every time
+		for i := 0; i < 5; i++ {
+			var sb strings.Builder
+			if err := prettyPrint(&sb, tt.data, "", nil); err != nil {
+				t.Fatal(err)
+			}
+			if got := sb.String(); got != tt.want {
+				t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package layered builds the effective configuration a service sees from
+// several files merged in order, ${VAR} references expanded and
+// environment overrides applied, remembering where every value came from.
+package layered
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// Config is a merged configuration
+type Config struct {
+	Doc interface{}
+	// Unset lists the variables referenced without a value or default
+	Unset []string
+	// Skipped lists the override variables whose path runs into an array
+	// through an index it does not have
+	Skipped []string
+
+	origins map[string]string // JSON pointer to the source of the value
+}
+
+// Source is one layer: a name for annotations and its document
+type Source struct {
+	Name string
+	Doc  interface{}
+}
+
+// Merge deep-merges the sources in order. Objects are merged member by
+// member; anything else, arrays included, replaces what earlier layers
+// had.
+func Merge(sources []Source) *Config {
+	c := &Config{origins: map[string]string{}}
+	for _, s := range sources {
+		c.Doc = c.merge(c.Doc, s.Doc, nil, s.Name)
+	}
+	return c
+}
+
+func (c *Config) merge(dst, src interface{}, path []string, origin string) interface{} {
+	d, dok := dst.(tree.Object)
+	s, sok := src.(tree.Object)
+	if !dok || !sok {
+		c.claim(src, path, origin)
+		return clone(src)
+	}
+	c.origins[tree.Pointer(path...)] = origin
+	for _, m := range s {
+		p := append(path[:len(path):len(path)], m.Key)
+		old, _ := d.Get(m.Key)
+		d = set(d, m.Key, c.merge(old, m.Value, p, origin))
+	}
+	return d
+}
+
+// claim records origin for v and everything below it, forgetting what
+// earlier layers left there
+func (c *Config) claim(v interface{}, path []string, origin string) {
+	prefix := tree.Pointer(path...)
+	for ptr := range c.origins {
+		if ptr == prefix || strings.HasPrefix(ptr, prefix+"/") {
+			delete(c.origins, ptr)
+		}
+	}
+	var walk func(v interface{}, path []string)
+	walk = func(v interface{}, path []string) {
+		c.origins[tree.Pointer(path...)] = origin
+		switch x := v.(type) {
+		case tree.Object:
+			for _, m := range x {
+				walk(m.Value, append(path[:len(path):len(path)], m.Key))
+			}
+		case []interface{}:
+			for i, e := range x {
+				walk(e, append(path[:len(path):len(path)], strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(v, path)
+}
+
+// Origin returns where the value at the JSON pointer ptr came from
+func (c *Config) Origin(ptr string) string {
+	return c.origins[ptr]
+}
+
+// Expand replaces ${VAR} and ${VAR:-default} in every string value with
+// what lookup returns; $$ stands for a literal $. Expanded values are
+// annotated with the variables they used.
+func (c *Config) Expand(lookup func(string) (string, bool)) {
+	unset := map[string]bool{}
+	c.Doc = c.expand(c.Doc, nil, lookup, unset)
+	for name := range unset {
+		c.Unset = append(c.Unset, name)
+	}
+	sort.Strings(c.Unset)
+}
+
+func (c *Config) expand(v interface{}, path []string, lookup func(string) (string, bool), unset map[string]bool) interface{} {
+	switch x := v.(type) {
+	case tree.Object:
+		for i, m := range x {
+			x[i].Value = c.expand(m.Value, append(path[:len(path):len(path)], m.Key), lookup, unset)
+		}
+	case []interface{}:
+		for i, e := range x {
+			x[i] = c.expand(e, append(path[:len(path):len(path)], strconv.Itoa(i)), lookup, unset)
+		}
+	case string:
+		s, used := expandString(x, lookup, unset)
+		if len(used) > 0 {
+			ptr := tree.Pointer(path...)
+			c.origins[ptr] += " via ${" + strings.Join(used, "}, ${") + "}"
+		}
+		return s
+	}
+	return v
+}
+
+// expandString expands the references in s and returns the names used
+func expandString(s string, lookup func(string) (string, bool), unset map[string]bool) (string, []string) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var sb strings.Builder
+	var used []string
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i+1 == len(s) {
+			sb.WriteString(s)
+			return sb.String(), used
+		}
+		sb.WriteString(s[:i])
+		s = s[i:]
+		switch {
+		case s[1] == '$':
+			sb.WriteByte('$')
+			s = s[2:]
+			continue
+		case s[1] != '{':
+			sb.WriteByte('$')
+			s = s[1:]
+			continue
+		}
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			sb.WriteString(s)
+			return sb.String(), used
+		}
+		name, def, hasDef := strings.Cut(s[2:end], ":-")
+		s = s[end+1:]
+		used = append(used, name)
+		val, ok := lookup(name)
+		switch {
+		case ok && (val != "" || !hasDef):
+			sb.WriteString(val)
+		case hasDef:
+			sb.WriteString(def)
+		default:
+			unset[name] = true
+		}
+	}
+}
+
+// Override applies environment variables named prefix followed by a path,
+// APP_SHIPTO_CITY setting shipTo.city. Path segments are separated by
+// underscores and matched against existing keys ignoring case, so keys
+// with underscores of their own can be reached too; missing keys are
+// created in lower case. Arrays are only indexed, never extended or
+// replaced; variables naming an element they lack are left out and listed
+// in Skipped. environ holds KEY=value entries as os.Environ returns them.
+func (c *Config) Override(prefix string, environ []string) {
+	sort.Strings(environ)
+	for _, kv := range environ {
+		name, val, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		segs := strings.Split(strings.ToLower(name[len(prefix):]), "_")
+		doc, ok := c.override(c.Doc, segs, nil, val, "env "+name)
+		if !ok {
+			c.Skipped = append(c.Skipped, name)
+			continue
+		}
+		c.Doc = doc
+	}
+}
+
+// override returns v with val stored at segs, or false, leaving v as it
+// was, when segs index an array out of its range
+func (c *Config) override(v interface{}, segs, path []string, val, origin string) (interface{}, bool) {
+	if len(segs) == 0 {
+		v = envValue(val, v)
+		c.claim(v, path, origin)
+		return v, true
+	}
+	switch x := v.(type) {
+	case []interface{}:
+		i, err := strconv.Atoi(segs[0])
+		if err != nil || i < 0 || i >= len(x) {
+			return v, false
+		}
+		e, ok := c.override(x[i], segs[1:], append(path, segs[0]), val, origin)
+		if ok {
+			x[i] = e
+		}
+		return x, ok
+	case tree.Object:
+		// the longest run of segments naming an existing key wins
+		for n := len(segs); n > 0; n-- {
+			want := strings.Join(segs[:n], "_")
+			for i, m := range x {
+				if strings.EqualFold(m.Key, want) {
+					e, ok := c.override(m.Value, segs[n:], append(path, m.Key), val, origin)
+					if ok {
+						x[i].Value = e
+					}
+					return x, ok
+				}
+			}
+		}
+		e, _ := c.override(nil, segs[1:], append(path, segs[0]), val, origin)
+		return append(x, tree.Member{Key: segs[0], Value: e}), true
+	}
+	// a scalar, or nothing, in the way of a longer path becomes an object
+	return c.override(tree.Object{}, segs, path, val, origin)
+}
+
+// envValue converts an environment string for the place it goes to:
+// kept a string where a string was, and otherwise read as JSON when it
+// is valid JSON, so PORT=8080 stays a number
+func envValue(val string, old interface{}) interface{} {
+	if _, ok := old.(string); ok {
+		return val
+	}
+	if v, err := tree.Decode(bytes.NewReader([]byte(val))); err == nil {
+		return v
+	}
+	return val
+}
+
+func set(obj tree.Object, key string, v interface{}) tree.Object {
+	for i := range obj {
+		if obj[i].Key == key {
+			obj[i].Value = v
+			return obj
+		}
+	}
+	return append(obj, tree.Member{Key: key, Value: v})
+}
+
+// clone copies the containers of v so merging into the result cannot
+// change the source documents
+func clone(v interface{}) interface{} {
+	switch x := v.(type) {
+	case tree.Object:
+		obj := make(tree.Object, len(x))
+		for i, m := range x {
+			obj[i] = tree.Member{Key: m.Key, Value: clone(m.Value)}
+		}
+		return obj
+	case []interface{}:
+		list := make([]interface{}, len(x))
+		for i, e := range x {
+			list[i] = clone(e)
+		}
+		return list
+	}
+	return v
+}
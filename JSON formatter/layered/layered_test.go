@@ -0,0 +1,148 @@
+package layered
+
+import (
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/tree"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	v, err := tree.Decode(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("decoding %s: %v", s, err)
+	}
+	return v
+}
+
+// compact writes v as single-line JSON
+func compact(t *testing.T, v interface{}) string {
+	t.Helper()
+	enc, err := encode.New("compact", encode.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := enc.Encode(&sb, v); err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func TestMerge(t *testing.T) {
+	base := decode(t, `{"name":"shop","db":{"host":"localhost","port":5432},"tags":["a","b"]}`)
+	prod := decode(t, `{"db":{"host":"db.internal","pool":{"size":10}},"tags":["c"],"debug":false}`)
+	c := Merge([]Source{{"base.json", base}, {"prod.json", prod}})
+
+	want := `{"name":"shop","db":{"host":"db.internal","port":5432,"pool":{"size":10}},"tags":["c"],"debug":false}`
+	if got := compact(t, c.Doc); got != want {
+		t.Errorf("merged\n%s\nwant\n%s", got, want)
+	}
+	origins := map[string]string{
+		"/name":           "base.json",
+		"/db/host":        "prod.json",
+		"/db/port":        "base.json",
+		"/db/pool/size":   "prod.json",
+		"/tags":           "prod.json",
+		"/tags/0":         "prod.json",
+		"/tags/1":         "",
+		"/debug":          "prod.json",
+		"/does-not-exist": "",
+	}
+	for ptr, want := range origins {
+		if got := c.Origin(ptr); got != want {
+			t.Errorf("Origin(%s) = %q, want %q", ptr, got, want)
+		}
+	}
+	// the sources are left as they were
+	if got := compact(t, base); got != `{"name":"shop","db":{"host":"localhost","port":5432},"tags":["a","b"]}` {
+		t.Errorf("the base became %s", got)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	env := map[string]string{"HOST": "db", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	c := Merge([]Source{{"base.json", decode(t, `{
+		"url": "pg://${HOST}:${PORT:-5432}/x",
+		"list": ["${HOST}", 1],
+		"empty": "${EMPTY:-fallback}",
+		"plain": "${EMPTY}",
+		"cost": "$$5 and $HOST",
+		"open": "${HOST",
+		"missing": "${NOPE}${ALSO_NOPE}${NOPE}"
+	}`)}})
+	c.Expand(lookup)
+
+	want := `{"url":"pg://db:5432/x","list":["db",1],"empty":"fallback","plain":"","cost":"$5 and $HOST","open":"${HOST","missing":""}`
+	if got := compact(t, c.Doc); got != want {
+		t.Errorf("expanded\n%s\nwant\n%s", got, want)
+	}
+	if got := strings.Join(c.Unset, " "); got != "ALSO_NOPE NOPE" {
+		t.Errorf("unset %q", got)
+	}
+	if got := c.Origin("/url"); got != "base.json via ${HOST}, ${PORT}" {
+		t.Errorf("Origin(/url) = %q", got)
+	}
+	if got := c.Origin("/cost"); got != "base.json" {
+		t.Errorf("Origin(/cost) = %q", got)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		environ []string
+		want    string
+		skipped string
+	}{
+		{"nested key ignoring case", []string{"APP_SHIPTO_CITY=Springfield"},
+			`{"shipTo":{"city":"Springfield"},"max_conn":5,"port":"80","servers":[{"host":"a"}],"n":1}`, ""},
+		{"keys with underscores", []string{"APP_MAX_CONN=10"},
+			`{"shipTo":{"city":"Pretendville"},"max_conn":10,"port":"80","servers":[{"host":"a"}],"n":1}`, ""},
+		{"strings stay strings", []string{"APP_PORT=8080"},
+			`{"shipTo":{"city":"Pretendville"},"max_conn":5,"port":"8080","servers":[{"host":"a"}],"n":1}`, ""},
+		{"new keys in lower case", []string{"APP_NEW_KEY=true"},
+			`{"shipTo":{"city":"Pretendville"},"max_conn":5,"port":"80","servers":[{"host":"a"}],"n":1,"new":{"key":true}}`, ""},
+		{"array elements", []string{"APP_SERVERS_0_HOST=b"},
+			`{"shipTo":{"city":"Pretendville"},"max_conn":5,"port":"80","servers":[{"host":"b"}],"n":1}`, ""},
+		{"missing array elements are skipped", []string{"APP_SERVERS_3_HOST=b", "APP_SERVERS_X=1", "APP_N=2"},
+			`{"shipTo":{"city":"Pretendville"},"max_conn":5,"port":"80","servers":[{"host":"a"}],"n":2}`,
+			"APP_SERVERS_3_HOST APP_SERVERS_X"},
+		{"a scalar in the way", []string{"APP_N_X=1"},
+			`{"shipTo":{"city":"Pretendville"},"max_conn":5,"port":"80","servers":[{"host":"a"}],"n":{"x":1}}`, ""},
+		{"other variables", []string{"HOME=/root", "APP_=1", "APP"},
+			`{"shipTo":{"city":"Pretendville"},"max_conn":5,"port":"80","servers":[{"host":"a"}],"n":1}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decode(t, `{"shipTo":{"city":"Pretendville"},"max_conn":5,"port":"80","servers":[{"host":"a"}],"n":1}`)
+			c := Merge([]Source{{"base.json", doc}})
+			c.Override("APP_", tt.environ)
+			if got := compact(t, c.Doc); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+			if got := strings.Join(c.Skipped, " "); got != tt.skipped {
+				t.Errorf("skipped %q, want %q", got, tt.skipped)
+			}
+		})
+	}
+}
+
+func TestOverrideOrigin(t *testing.T) {
+	c := Merge([]Source{{"base.json", decode(t, `{"db":{"host":"a","port":1}}`)}})
+	c.Override("APP_", []string{"APP_DB={\"host\":\"b\"}"})
+	if got := compact(t, c.Doc); got != `{"db":{"host":"b"}}` {
+		t.Errorf("got %s", got)
+	}
+	for ptr, want := range map[string]string{"/db": "env APP_DB", "/db/host": "env APP_DB", "/db/port": ""} {
+		if got := c.Origin(ptr); got != want {
+			t.Errorf("Origin(%s) = %q, want %q", ptr, got, want)
+		}
+	}
+}
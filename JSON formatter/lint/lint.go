@@ -0,0 +1,358 @@
+// Package lint finds problems in JSON documents that are valid JSON but
+// likely mistakes: duplicate keys, which encoding/json and json.Valid
+// accept silently, mixed-type arrays, numbers float64 cannot hold, empty
+// keys, control characters and inconsistent key casing. It reads the
+// token stream, so it sees the document as written, duplicates included.
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"example.com/json-view-formatter/query"
+	"example.com/json-view-formatter/tree"
+)
+
+// Severity is how serious the findings of a rule are; Off disables it
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Note    Severity = "note"
+	Off     Severity = "off"
+)
+
+// Rule is a check the linter runs
+type Rule struct {
+	Name        string
+	Description string
+	Default     Severity
+}
+
+// Rules lists every rule in the order findings are explained
+var Rules = []Rule{
+	{"duplicate-key", "an object has the same key twice; decoders keep only one of the values", Error},
+	{"mixed-array", "an array holds elements of different types, nulls aside", Warning},
+	{"number-precision", "a number cannot be represented exactly as a float64", Warning},
+	{"empty-key", "an object member has an empty key", Warning},
+	{"control-character", "a string contains a control character other than tab, newline or carriage return", Warning},
+	{"key-casing", "a key uses a different casing convention than most keys in the document", Note},
+}
+
+// Config maps rule names to the severity they are reported with. Rules
+// missing from it use their default.
+type Config map[string]Severity
+
+// ParseConfig reads settings such as "key-casing=off,mixed-array=error"
+func ParseConfig(s string) (Config, error) {
+	cfg := Config{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, level, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("bad rule setting %q, want rule=error|warning|note|off", item)
+		}
+		if findRule(name) == nil {
+			return nil, fmt.Errorf("unknown lint rule %q", name)
+		}
+		switch sev := Severity(level); sev {
+		case Error, Warning, Note, Off:
+			cfg[name] = sev
+		default:
+			return nil, fmt.Errorf("bad severity %q for %s, want error, warning, note or off", level, name)
+		}
+	}
+	return cfg, nil
+}
+
+func findRule(name string) *Rule {
+	for i := range Rules {
+		if Rules[i].Name == name {
+			return &Rules[i]
+		}
+	}
+	return nil
+}
+
+func (c Config) severity(rule string) Severity {
+	if sev, ok := c[rule]; ok {
+		return sev
+	}
+	return findRule(rule).Default
+}
+
+// Finding is one problem. Offset is the byte offset in the document of
+// the key or value it is about and Path its location in query syntax.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	Offset   int
+	Path     string
+	Message  string
+}
+
+// maxDepth bounds nesting like encoding/json does
+const maxDepth = 10000
+
+// Lint checks a JSON document. The error is the decoder's for input that
+// is not valid JSON. Findings are ordered by position.
+func Lint(data []byte, cfg Config) ([]Finding, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	l := &linter{data: data, dec: dec, cfg: cfg}
+	tok, off, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+	if err := l.value(tok, off, "$", 0); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("invalid character after top-level value at offset %d", dec.InputOffset())
+		}
+		return nil, err
+	}
+	l.checkCasing()
+	sort.SliceStable(l.findings, func(i, j int) bool { return l.findings[i].Offset < l.findings[j].Offset })
+	return l.findings, nil
+}
+
+type linter struct {
+	data     []byte
+	dec      *json.Decoder
+	cfg      Config
+	findings []Finding
+	keys     []keyUse
+}
+
+// keyUse is a key seen in the document, kept for the casing check
+type keyUse struct {
+	key    string
+	style  string
+	offset int
+	path   string
+}
+
+func (l *linter) report(rule string, offset int, path, format string, args ...interface{}) {
+	sev := l.cfg.severity(rule)
+	if sev == Off {
+		return
+	}
+	l.findings = append(l.findings, Finding{Rule: rule, Severity: sev, Offset: offset, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// next reads a token and the offset it starts at: the decoder's offset
+// is just past the previous token, before any separator
+func (l *linter) next() (json.Token, int, error) {
+	off := int(l.dec.InputOffset())
+	for off < len(l.data) && strings.IndexByte(" \t\r\n,:", l.data[off]) >= 0 {
+		off++
+	}
+	tok, err := l.dec.Token()
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return tok, off, err
+}
+
+func (l *linter) value(tok json.Token, off int, path string, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("exceeded max depth at offset %d", off)
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			return l.object(path, depth)
+		}
+		return l.array(off, path, depth)
+	case string:
+		l.checkString(t, off, path, "string")
+	case json.Number:
+		l.checkNumber(t, off, path)
+	}
+	return nil
+}
+
+func (l *linter) object(path string, depth int) error {
+	seen := map[string]int{}
+	for {
+		tok, off, err := l.next()
+		if err != nil {
+			return err
+		}
+		if tok == json.Delim('}') {
+			return nil
+		}
+		key := tok.(string)
+		child := query.Join(path, key)
+		if first, dup := seen[key]; dup {
+			l.report("duplicate-key", off, child, "duplicate key %q, first at line %d; only one of the values survives decoding", key, l.line(first))
+		} else {
+			seen[key] = off
+		}
+		if key == "" {
+			l.report("empty-key", off, child, "empty key")
+		}
+		l.checkString(key, off, child, "key")
+		if style := keyStyle(key); style != "" {
+			l.keys = append(l.keys, keyUse{key, style, off, child})
+		}
+		if tok, off, err = l.next(); err != nil {
+			return err
+		}
+		if err := l.value(tok, off, child, depth+1); err != nil {
+			return err
+		}
+	}
+}
+
+func (l *linter) array(start int, path string, depth int) error {
+	var kinds []string
+	for i := 0; ; i++ {
+		tok, off, err := l.next()
+		if err != nil {
+			return err
+		}
+		if tok == json.Delim(']') {
+			break
+		}
+		if k := tokenKind(tok); k != "null" && !contains(kinds, k) {
+			kinds = append(kinds, k)
+		}
+		if err := l.value(tok, off, path+"["+strconv.Itoa(i)+"]", depth+1); err != nil {
+			return err
+		}
+	}
+	if len(kinds) > 1 {
+		l.report("mixed-array", start, path, "array mixes %s elements", strings.Join(kinds, ", "))
+	}
+	return nil
+}
+
+func (l *linter) checkString(s string, off int, path, what string) {
+	for _, r := range s {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' || r == 0x7f {
+			l.report("control-character", off, path, "%s contains control character U+%04X", what, r)
+			return
+		}
+	}
+}
+
+func (l *linter) checkNumber(n json.Number, off int, path string) {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) {
+		l.report("number-precision", off, path, "number %s is out of float64 range", n)
+		return
+	}
+	if c, ok := tree.CompareNumbers(n, f); ok && c != 0 {
+		l.report("number-precision", off, path, "number %s is not exact as float64, it reads as %s", n, strconv.FormatFloat(f, 'g', -1, 64))
+	}
+}
+
+// checkCasing reports the keys whose style is not the one most keys use,
+// the first seen winning a tie
+func (l *linter) checkCasing() {
+	count := map[string]int{}
+	var order []string
+	for _, k := range l.keys {
+		if count[k.style] == 0 {
+			order = append(order, k.style)
+		}
+		count[k.style]++
+	}
+	if len(order) < 2 {
+		return
+	}
+	common := order[0]
+	for _, s := range order[1:] {
+		if count[s] > count[common] {
+			common = s
+		}
+	}
+	for _, k := range l.keys {
+		if k.style != common {
+			l.report("key-casing", k.offset, k.path, "key %q is %s, most keys are %s", k.key, k.style, common)
+		}
+	}
+}
+
+// keyStyle names the casing convention of a key, "" for keys that fit
+// any convention, such as single lower case words, or none
+func keyStyle(key string) string {
+	lower, upper := false, false
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return ""
+		}
+	}
+	under, dash := strings.Contains(key, "_"), strings.Contains(key, "-")
+	switch {
+	case under && dash, !lower && !upper:
+		return ""
+	case under && !lower:
+		return "SCREAMING_SNAKE_CASE"
+	case under && !upper:
+		return "snake_case"
+	case dash && !upper:
+		return "kebab-case"
+	case under, dash:
+		return ""
+	case !upper:
+		return ""
+	case key[0] >= 'A' && key[0] <= 'Z':
+		if !lower {
+			return ""
+		}
+		return "PascalCase"
+	}
+	return "camelCase"
+}
+
+func tokenKind(tok json.Token) string {
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			return "object"
+		}
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// line is the 1-based line of offset
+func (l *linter) line(offset int) int {
+	return bytes.Count(l.data[:offset], []byte("\n")) + 1
+}
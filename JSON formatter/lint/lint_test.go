@@ -0,0 +1,108 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// show formats findings as "rule severity path @offset"
+func show(findings []Finding) string {
+	parts := make([]string, len(findings))
+	for i, f := range findings {
+		parts[i] = fmt.Sprintf("%s %s %s @%d", f.Rule, f.Severity, f.Path, f.Offset)
+	}
+	return strings.Join(parts, "\n")
+}
+
+var lintTests = []struct {
+	name, doc, rules string
+	want             []string
+}{
+	{"clean", `{"a":1,"b":[1,null,2],"c":{"d":"x"}}`, "", nil},
+	{"duplicate key", `{"a":1,"b":2,"a":3}`, "", []string{"duplicate-key error $.a @13"}},
+	{"duplicates in nested objects only count there", `{"a":{"x":1},"b":{"x":2}}`, "", nil},
+	{"mixed array", `{"a":[1,"x",null,{}]}`, "", []string{"mixed-array warning $.a @5"}},
+	{"number beyond float64", `[1e400]`, "", []string{"number-precision warning $[0] @1"}},
+	{"number float64 rounds", `[9007199254740993, 0.1, 1.50]`, "", []string{"number-precision warning $[0] @1"}},
+	{"empty key", `{"":1}`, "", []string{"empty-key warning $[\"\"] @1"}},
+	{"control characters", `{"a\u0001":"\u007f","t":"\t\n"}`, "",
+		[]string{"control-character warning $[\"a\\x01\"] @1", "control-character warning $[\"a\\x01\"] @11"}},
+	{"key casing", `{"firstName":"a","lastName":"b","zip_code":1,"id":2}`, "",
+		[]string{"key-casing note $.zip_code @32"}},
+	{"severity raised", `[1,"a"]`, "mixed-array=error", []string{"mixed-array error $ @0"}},
+	{"rule off", `{"a":1,"a":2}`, "duplicate-key=off", nil},
+	{"findings by position", "{\n\"b\":[1,\"x\"],\n\"\":1e400}", "",
+		[]string{"mixed-array warning $.b @6", "empty-key warning $[\"\"] @15", "number-precision warning $[\"\"] @18"}},
+}
+
+func TestLint(t *testing.T) {
+	for _, tt := range lintTests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			findings, err := Lint([]byte(tt.doc), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := show(findings), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("findings:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestDuplicateMessage(t *testing.T) {
+	findings, err := Lint([]byte("{\n\"a\": 1,\n\"a\": 2}"), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "first at line 2") {
+		t.Errorf("findings %v, want one pointing at line 2", findings)
+	}
+}
+
+func TestLintErrors(t *testing.T) {
+	for _, doc := range []string{``, `{"a":}`, `[1,2`, `{} {}`, `{"a":1,}`} {
+		if f, err := Lint([]byte(doc), Config{}); err == nil {
+			t.Errorf("Lint(%q) = %v, want an error", doc, f)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(" key-casing=off, mixed-array=error ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg["key-casing"] != Off || cfg["mixed-array"] != Error || len(cfg) != 2 {
+		t.Errorf("got %v", cfg)
+	}
+	for _, s := range []string{"key-casing", "nope=error", "key-casing=loud"} {
+		if _, err := ParseConfig(s); err == nil {
+			t.Errorf("ParseConfig(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestKeyStyle(t *testing.T) {
+	tests := []struct{ key, want string }{
+		{"firstName", "camelCase"},
+		{"FirstName", "PascalCase"},
+		{"first_name", "snake_case"},
+		{"FIRST_NAME", "SCREAMING_SNAKE_CASE"},
+		{"first-name", "kebab-case"},
+		{"name", ""},
+		{"ID", ""},
+		{"first_Name", ""},
+		{"a_b-c", ""},
+		{"héllo", ""},
+	}
+	for _, tt := range tests {
+		if got := keyStyle(tt.key); got != tt.want {
+			t.Errorf("keyStyle(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"example.com/json-view-formatter/decode"
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/lint"
+	"example.com/json-view-formatter/tree"
+)
+
+// sarifSchema identifies the SARIF version the lint output follows
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// lintResult is the findings for one file with their positions
+type lintResult struct {
+	name     string
+	uri      string
+	findings []lint.Finding
+	lines    *lineIndex
+}
+
+// runLint checks JSON files for valid but suspicious content and lists
+// what it finds as text or as a SARIF log for code scanning tools. It
+// exits non-zero when a finding has error severity.
+func runLint(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	names := make([]string, len(lint.Rules))
+	for i, r := range lint.Rules {
+		names[i] = r.Name
+	}
+	flags := newFlagSet("jsonFormatViewer lint", "file|glob|-...", stderr)
+	rules := flags.String("rules", "", "comma-separated `rule=severity` settings, the severity being error, warning, note or off; rules: "+strings.Join(names, ", "))
+	format := flags.String("o", "text", "output `format`: text or sarif")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+	if *format != "text" && *format != "sarif" {
+		fmt.Fprintf(stderr, "bad -o %q, want text or sarif\n", *format)
+		return exitUsage
+	}
+	cfg, err := lint.ParseConfig(*rules)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	paths, err := expandArgs(flags.Args())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	code := exitOK
+	var results []lintResult
+	for _, path := range paths {
+		name := displayName(path)
+		if f := decode.Detect(path); f != decode.JSON {
+			fmt.Fprintf(stderr, "%s: lint reads JSON, not %s\n", name, f)
+			code = max(code, exitUsage)
+			continue
+		}
+		data, err := readSource(path, stdin)
+		if err != nil {
+			code = max(code, reportError(stderr, name, err))
+			continue
+		}
+		findings, err := lint.Lint(data, cfg)
+		if err != nil {
+			code = max(code, reportSyntax(stderr, name, data, err, false))
+			continue
+		}
+		for _, f := range findings {
+			if f.Severity == lint.Error {
+				code = max(code, exitLint)
+			}
+		}
+		uri := filepath.ToSlash(path)
+		if path == stdinName {
+			uri = "stdin"
+		}
+		results = append(results, lintResult{name: name, uri: uri, findings: findings, lines: newLineIndex(data)})
+	}
+
+	out := bufio.NewWriter(stdout)
+	if *format == "sarif" {
+		enc, _ := encode.New("json", encode.DefaultOptions)
+		err = enc.Encode(out, sarifLog(results, cfg))
+	} else {
+		for _, r := range results {
+			for _, f := range r.findings {
+				line, col := r.lines.position(f.Offset)
+				fmt.Fprintf(out, "%s:%d:%d: %s: %s: %s [%s]\n", r.name, line, col, f.Severity, f.Path, f.Message, f.Rule)
+			}
+		}
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		return reportError(stderr, "lint", &writeError{err})
+	}
+	return code
+}
+
+// sarifLog builds a SARIF 2.1.0 log with one run holding the findings of
+// every file. Columns count characters, as in the text output.
+func sarifLog(results []lintResult, cfg lint.Config) tree.Object {
+	text := func(s string) tree.Object { return tree.Object{{Key: "text", Value: s}} }
+	num := func(n int) json.Number { return json.Number(strconv.Itoa(n)) }
+
+	var rules []interface{}
+	for _, r := range lint.Rules {
+		level := r.Default
+		if sev, ok := cfg[r.Name]; ok {
+			level = sev
+		}
+		rule := tree.Object{
+			{Key: "id", Value: r.Name},
+			{Key: "shortDescription", Value: text(r.Description)},
+			{Key: "defaultConfiguration", Value: tree.Object{
+				{Key: "enabled", Value: level != lint.Off},
+				{Key: "level", Value: sarifLevel(level)},
+			}},
+		}
+		rules = append(rules, rule)
+	}
+
+	list := []interface{}{}
+	for _, r := range results {
+		for _, f := range r.findings {
+			line, col := r.lines.position(f.Offset)
+			list = append(list, tree.Object{
+				{Key: "ruleId", Value: f.Rule},
+				{Key: "level", Value: sarifLevel(f.Severity)},
+				{Key: "message", Value: text(f.Message)},
+				{Key: "locations", Value: []interface{}{tree.Object{
+					{Key: "physicalLocation", Value: tree.Object{
+						{Key: "artifactLocation", Value: tree.Object{{Key: "uri", Value: r.uri}}},
+						{Key: "region", Value: tree.Object{
+							{Key: "startLine", Value: num(line)},
+							{Key: "startColumn", Value: num(col)},
+						}},
+					}},
+					{Key: "logicalLocations", Value: []interface{}{tree.Object{
+						{Key: "fullyQualifiedName", Value: f.Path},
+					}}},
+				}}},
+			})
+		}
+	}
+
+	run := tree.Object{
+		{Key: "tool", Value: tree.Object{{Key: "driver", Value: tree.Object{
+			{Key: "name", Value: "jsonFormatViewer lint"},
+			{Key: "rules", Value: rules},
+		}}}},
+		{Key: "columnKind", Value: "unicodeCodePoints"},
+		{Key: "results", Value: list},
+	}
+	return tree.Object{
+		{Key: "$schema", Value: sarifSchema},
+		{Key: "version", Value: "2.1.0"},
+		{Key: "runs", Value: []interface{}{run}},
+	}
+}
+
+// sarifLevel maps a severity to a SARIF level; disabled rules are "none"
+func sarifLevel(s lint.Severity) string {
+	if s == lint.Off {
+		return "none"
+	}
+	return string(s)
+}
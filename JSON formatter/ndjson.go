@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/redact"
+	"example.com/json-view-formatter/tree"
+)
+
+// ndjsonBatch is how many lines a worker takes at a time; batching keeps
+// the channel traffic low for millions of short records
+const ndjsonBatch = 256
+
+// ndjsonExtensions mark files holding one JSON document per line
+var ndjsonExtensions = map[string]bool{".ndjson": true, ".jsonl": true}
+
+// isNDJSON reports whether path is named like a JSON Lines file
+func isNDJSON(path string) bool {
+	return ndjsonExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// lineBatch is a run of input lines and, once a worker is done with it,
+// what they print
+type lineBatch struct {
+	first int // line number of lines[0]
+	lines [][]byte
+	out   bytes.Buffer
+	errs  bytes.Buffer
+	bad   int
+	code  int
+	done  chan struct{}
+}
+
+// viewLines formats every line of src as a document of its own. Lines are
+// decoded and rendered by a pool of workers, one per CPU, and printed in
+// input order; a bad line is reported by its number and the rest still
+// go through.
+func viewLines(out *bufio.Writer, stderr io.Writer, src io.Reader, name string, opts *options) int {
+	work := make(chan *lineBatch)
+	workers := runtime.GOMAXPROCS(0)
+	// batches wait here in input order; the bound keeps memory flat when
+	// the output is slower than the workers
+	ordered := make(chan *lineBatch, 2*workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range work {
+				formatLines(b, name, opts)
+				close(b.done)
+			}
+		}()
+	}
+
+	var readErr error
+	go func() {
+		readErr = readLines(src, func(b *lineBatch) {
+			ordered <- b
+			work <- b
+		})
+		close(work)
+		close(ordered)
+	}()
+
+	if opts.encoder == nil && opts.schema == nil {
+		fmt.Fprintf(out, "=== %s ===\n", name)
+	}
+	code, lines, bad := exitOK, 0, 0
+	var writeErr error
+	for b := range ordered {
+		<-b.done
+		lines += len(b.lines)
+		bad += b.bad
+		if b.code > code {
+			code = b.code
+		}
+		if writeErr == nil {
+			_, writeErr = b.out.WriteTo(out)
+		}
+		if b.errs.Len() > 0 {
+			// flush first so the report lands after the records before it
+			if writeErr == nil {
+				writeErr = out.Flush()
+			}
+			b.errs.WriteTo(stderr)
+		}
+	}
+	wg.Wait()
+
+	if writeErr == nil {
+		writeErr = out.Flush()
+	}
+	if writeErr != nil {
+		return reportError(stderr, name, &writeError{writeErr})
+	}
+	if readErr != nil {
+		return reportError(stderr, name, readErr)
+	}
+	if bad > 0 {
+		fmt.Fprintf(stderr, "%s: %d of %d records invalid\n", name, bad, lines)
+	} else if opts.schema != nil {
+		fmt.Fprintf(out, "%s: %d records valid against %s\n", name, lines, opts.schemaName)
+		if err := out.Flush(); err != nil {
+			return reportError(stderr, name, &writeError{err})
+		}
+	}
+	return code
+}
+
+// readLines cuts src into batches of non-blank lines, numbering them as
+// in the file
+func readLines(src io.Reader, emit func(*lineBatch)) error {
+	r := bufio.NewReaderSize(src, 64*1024)
+	b := &lineBatch{first: 1, done: make(chan struct{})}
+	n := 0
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			n++
+			if len(bytes.TrimSpace(line)) > 0 {
+				if len(b.lines) == 0 {
+					b.first = n
+				}
+				b.lines = append(b.lines, line)
+				if len(b.lines) == ndjsonBatch {
+					emit(b)
+					b = &lineBatch{done: make(chan struct{})}
+				}
+			} else if len(b.lines) > 0 {
+				// a batch must stay a run of consecutive lines
+				emit(b)
+				b = &lineBatch{done: make(chan struct{})}
+			}
+		}
+		if err != nil {
+			if len(b.lines) > 0 {
+				emit(b)
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// formatLines decodes and renders the lines of b into its buffers
+func formatLines(b *lineBatch, name string, opts *options) {
+	for i, line := range b.lines {
+		n := b.first + i
+		line = bytes.TrimRight(line, "\r\n")
+		doc, err := tree.Decode(bytes.NewReader(line))
+		if err != nil {
+			b.bad++
+			b.code = max(b.code, exitInvalid)
+			if issues := checkSyntax(line, opts.allErrors); len(issues) > 0 {
+				writeSyntaxReportAt(&b.errs, name, line, n, issues)
+			} else {
+				fmt.Fprintf(&b.errs, "%s:%d: %v\n", name, n, err)
+			}
+			continue
+		}
+		if opts.normalize {
+			doc = tree.NormalizeNumbers(doc)
+		}
+
+		if opts.schema != nil {
+			violations := opts.schema.Validate(doc)
+			for _, v := range violations {
+				fmt.Fprintf(&b.errs, "%s:%d: %s\n", name, n, v)
+			}
+			if len(violations) > 0 {
+				b.bad++
+				b.code = max(b.code, exitSchema)
+			}
+			continue
+		}
+
+		if opts.redactor != nil {
+			var found []redact.Finding
+			doc, found = opts.redactor.Redact(doc)
+			for _, f := range found {
+				fmt.Fprintf(&b.errs, "%s:%d: redacted %s\n", name, n, f)
+			}
+		}
+
+		if opts.query != nil {
+			var ok bool
+			if doc, ok = selectResult(opts.query, doc); !ok {
+				// records without the path are filtered out, as with jq
+				continue
+			}
+		}
+
+		if opts.encoder != nil {
+			err := opts.encoder.Encode(&b.out, doc)
+			var ue *encode.UnrepresentableError
+			if errors.As(err, &ue) {
+				fmt.Fprintf(&b.errs, "%s:%d: %v\n", name, n, err)
+				b.code = max(b.code, exitUnsupported)
+			}
+			continue
+		}
+		fmt.Fprintf(&b.out, "--- line %d ---\n", n)
+		prettyPrint(&b.out, doc, "  ", opts.theme)
+	}
+}
@@ -0,0 +1,318 @@
+package patch
+
+import (
+	"fmt"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// OpError is an operation that could not be parsed or applied. Index
+// counts from 0 and Pointer is the path or from member the operation
+// failed on.
+type OpError struct {
+	Index   int
+	Op      string
+	Pointer string
+	Err     error
+}
+
+func (e *OpError) Error() string {
+	if e.Op == "" {
+		return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+	}
+	return fmt.Sprintf("operation %d (%s %s): %v", e.Index, e.Op, e.Pointer, e.Err)
+}
+
+func (e *OpError) Unwrap() error { return e.Err }
+
+// Parse reads a JSON Patch document, an array of operation objects
+func Parse(doc interface{}) ([]Op, error) {
+	list, ok := doc.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("a JSON Patch must be an array of operations")
+	}
+	ops := make([]Op, len(list))
+	for i, item := range list {
+		obj, ok := item.(tree.Object)
+		if !ok {
+			return nil, &OpError{Index: i, Err: fmt.Errorf("not an object")}
+		}
+		op, err := parseOp(obj)
+		if err != nil {
+			return nil, &OpError{Index: i, Op: op.Op, Pointer: op.Path, Err: err}
+		}
+		ops[i] = op
+	}
+	return ops, nil
+}
+
+func parseOp(obj tree.Object) (Op, error) {
+	var op Op
+	var err error
+	if op.Op, err = stringMember(obj, "op"); err != nil {
+		return op, err
+	}
+	if op.Path, err = stringMember(obj, "path"); err != nil {
+		return op, err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		v, ok := obj.Get("value")
+		if !ok {
+			return op, fmt.Errorf("missing member \"value\"")
+		}
+		op.Value = v
+	case "move", "copy":
+		if op.From, err = stringMember(obj, "from"); err != nil {
+			return op, err
+		}
+	case "remove":
+	default:
+		return op, fmt.Errorf("unknown operation %q", op.Op)
+	}
+	return op, nil
+}
+
+func stringMember(obj tree.Object, key string) (string, error) {
+	v, ok := obj.Get(key)
+	if !ok {
+		return "", fmt.Errorf("missing member %q", key)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("member %q must be a string", key)
+	}
+	return s, nil
+}
+
+// Apply returns doc with the operations applied in order. It works on a
+// copy, so when an operation fails doc is left as it was and the error is
+// an *OpError naming the operation.
+func Apply(doc interface{}, ops []Op) (interface{}, error) {
+	doc = clone(doc)
+	for i, op := range ops {
+		var ptr string
+		var err error
+		doc, ptr, err = applyOp(doc, op)
+		if err != nil {
+			return nil, &OpError{Index: i, Op: op.Op, Pointer: ptr, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+// applyOp applies one operation and on failure also returns the pointer
+// it failed on
+func applyOp(doc interface{}, op Op) (interface{}, string, error) {
+	path, err := tree.ParsePointer(op.Path)
+	if err != nil {
+		return nil, op.Path, err
+	}
+	switch op.Op {
+	case "add":
+		doc, err = add(doc, path, clone(op.Value))
+	case "remove":
+		doc, _, err = remove(doc, path)
+	case "replace":
+		if _, err = get(doc, path); err == nil {
+			doc, err = replace(doc, path, clone(op.Value))
+		}
+	case "test":
+		var v interface{}
+		if v, err = get(doc, path); err == nil && !tree.Equal(v, op.Value) {
+			err = fmt.Errorf("value differs")
+		}
+	case "move", "copy":
+		from, ferr := tree.ParsePointer(op.From)
+		if ferr != nil {
+			return nil, op.From, ferr
+		}
+		var v interface{}
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, op.From, fmt.Errorf("cannot move a value into itself")
+			}
+			doc, v, err = remove(doc, from)
+		} else {
+			v, err = get(doc, from)
+			v = clone(v)
+		}
+		if err != nil {
+			return nil, op.From, err
+		}
+		doc, err = add(doc, path, v)
+	default:
+		err = fmt.Errorf("unknown operation %q", op.Op)
+	}
+	if err != nil {
+		return nil, op.Path, err
+	}
+	return doc, "", nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	return tree.Resolve(doc, tree.Pointer(path...))
+}
+
+// add inserts v at path: a new or replaced object member, or an array
+// element shifting the ones after it
+func add(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return update(doc, path, func(parent interface{}, t string) (interface{}, error) {
+		switch c := parent.(type) {
+		case tree.Object:
+			return set(c, t, v), nil
+		case []interface{}:
+			i, err := tree.ArrayIndex(t, len(c))
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = v
+			return c, nil
+		}
+		return nil, fmt.Errorf("cannot add to a %s", kind(parent))
+	})
+}
+
+// replace stores v at an existing path
+func replace(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return update(doc, path, func(parent interface{}, t string) (interface{}, error) {
+		switch c := parent.(type) {
+		case tree.Object:
+			return set(c, t, v), nil
+		case []interface{}:
+			i, _ := tree.ArrayIndex(t, len(c))
+			c[i] = v
+			return c, nil
+		}
+		return nil, fmt.Errorf("cannot replace in a %s", kind(parent))
+	})
+}
+
+// remove deletes the value at path and returns it
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+	var removed interface{}
+	doc, err := update(doc, path, func(parent interface{}, t string) (interface{}, error) {
+		switch c := parent.(type) {
+		case tree.Object:
+			for i, m := range c {
+				if m.Key == t {
+					removed = m.Value
+					return append(c[:i], c[i+1:]...), nil
+				}
+			}
+			return nil, fmt.Errorf("no member %q", t)
+		case []interface{}:
+			i, err := tree.ArrayIndex(t, len(c))
+			if err != nil || i == len(c) {
+				return nil, fmt.Errorf("bad array index %q", t)
+			}
+			removed = c[i]
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("cannot remove from a %s", kind(parent))
+	})
+	return doc, removed, err
+}
+
+// update finds the container holding the last token of path, replaces it
+// with what fn makes of it and returns the document with the change
+func update(doc interface{}, path []string, fn func(parent interface{}, t string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	t := path[0]
+	switch c := doc.(type) {
+	case tree.Object:
+		child, ok := c.Get(t)
+		if !ok {
+			return nil, fmt.Errorf("no member %q", t)
+		}
+		child, err := update(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		return set(c, t, child), nil
+	case []interface{}:
+		i, err := tree.ArrayIndex(t, len(c))
+		if err != nil || i == len(c) {
+			return nil, fmt.Errorf("bad array index %q", t)
+		}
+		child, err := update(c[i], path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = child
+		return c, nil
+	}
+	return nil, fmt.Errorf("%q is inside a %s", t, kind(doc))
+}
+
+func set(obj tree.Object, key string, v interface{}) tree.Object {
+	for i := range obj {
+		if obj[i].Key == key {
+			obj[i].Value = v
+			return obj
+		}
+	}
+	return append(obj, tree.Member{Key: key, Value: v})
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// clone copies the containers of v so changes to the copy do not show
+// through to v
+func clone(v interface{}) interface{} {
+	switch c := v.(type) {
+	case tree.Object:
+		obj := make(tree.Object, len(c))
+		for i, m := range c {
+			obj[i] = tree.Member{Key: m.Key, Value: clone(m.Value)}
+		}
+		return obj
+	case []interface{}:
+		list := make([]interface{}, len(c))
+		for i, e := range c {
+			list[i] = clone(e)
+		}
+		return list
+	}
+	return v
+}
+
+// kind names the JSON type of a value for messages
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case tree.Object:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return "number"
+}
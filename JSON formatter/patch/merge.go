@@ -0,0 +1,36 @@
+package patch
+
+import "example.com/json-view-formatter/tree"
+
+// Merge applies an RFC 7396 JSON Merge Patch: object members of patch
+// are merged into doc recursively, null members delete, and any other
+// patch value replaces the target. Neither argument is modified.
+func Merge(doc, patch interface{}) interface{} {
+	p, ok := patch.(tree.Object)
+	if !ok {
+		return clone(patch)
+	}
+	target, ok := doc.(tree.Object)
+	if !ok {
+		target = tree.Object{}
+	}
+	target = clone(target).(tree.Object)
+	for _, m := range p {
+		if m.Value == nil {
+			target = without(target, m.Key)
+			continue
+		}
+		old, _ := target.Get(m.Key)
+		target = set(target, m.Key, Merge(old, m.Value))
+	}
+	return target
+}
+
+func without(obj tree.Object, key string) tree.Object {
+	for i, m := range obj {
+		if m.Key == key {
+			return append(obj[:i], obj[i+1:]...)
+		}
+	}
+	return obj
+}
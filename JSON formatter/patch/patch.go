@@ -0,0 +1,42 @@
+// Package patch builds and applies RFC 6902 JSON Patches and applies
+// RFC 7396 JSON Merge Patches to value trees.
+package patch
+
+import "example.com/json-view-formatter/tree"
+
+// Op is one JSON Patch operation. Value is used by add, replace and test,
+// From by move and copy.
+type Op struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+}
+
+// hasValue reports whether the operation carries a value member
+func (o Op) hasValue() bool {
+	return o.Op == "add" || o.Op == "replace" || o.Op == "test"
+}
+
+// Tree returns the operation as a JSON object with its members in the
+// order the RFC lists them
+func (o Op) Tree() tree.Object {
+	obj := tree.Object{{Key: "op", Value: o.Op}}
+	if o.Op == "move" || o.Op == "copy" {
+		obj = append(obj, tree.Member{Key: "from", Value: o.From})
+	}
+	obj = append(obj, tree.Member{Key: "path", Value: o.Path})
+	if o.hasValue() {
+		obj = append(obj, tree.Member{Key: "value", Value: o.Value})
+	}
+	return obj
+}
+
+// Document returns a patch as the JSON array that is written to files
+func Document(ops []Op) []interface{} {
+	doc := make([]interface{}, len(ops))
+	for i, op := range ops {
+		doc[i] = op.Tree()
+	}
+	return doc
+}
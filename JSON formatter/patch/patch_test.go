@@ -0,0 +1,151 @@
+package patch
+
+import (
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/tree"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	v, err := tree.Decode(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("decoding %s: %v", s, err)
+	}
+	return v
+}
+
+// the examples of RFC 6902, appendix A; want is empty where the patch
+// must fail
+var applyTests = []struct {
+	name, doc, patch, want string
+}{
+	{"A.1 adding an object member", `{"foo":"bar"}`,
+		`[{"op":"add","path":"/baz","value":"qux"}]`,
+		`{"baz":"qux","foo":"bar"}`},
+	{"A.2 adding an array element", `{"foo":["bar","baz"]}`,
+		`[{"op":"add","path":"/foo/1","value":"qux"}]`,
+		`{"foo":["bar","qux","baz"]}`},
+	{"A.3 removing an object member", `{"baz":"qux","foo":"bar"}`,
+		`[{"op":"remove","path":"/baz"}]`,
+		`{"foo":"bar"}`},
+	{"A.4 removing an array element", `{"foo":["bar","qux","baz"]}`,
+		`[{"op":"remove","path":"/foo/1"}]`,
+		`{"foo":["bar","baz"]}`},
+	{"A.5 replacing a value", `{"baz":"qux","foo":"bar"}`,
+		`[{"op":"replace","path":"/baz","value":"boo"}]`,
+		`{"baz":"boo","foo":"bar"}`},
+	{"A.6 moving a value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+		`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+		`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+	{"A.7 moving an array element", `{"foo":["all","grass","cows","eat"]}`,
+		`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+		`{"foo":["all","cows","eat","grass"]}`},
+	{"A.8 testing a value: success", `{"baz":"qux","foo":["a",2,"c"]}`,
+		`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+		`{"baz":"qux","foo":["a",2,"c"]}`},
+	{"A.9 testing a value: error", `{"baz":"qux"}`,
+		`[{"op":"test","path":"/baz","value":"bar"}]`,
+		``},
+	{"A.10 adding a nested member object", `{"foo":"bar"}`,
+		`[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+		`{"foo":"bar","child":{"grandchild":{}}}`},
+	{"A.11 ignoring unrecognized elements", `{"foo":"bar"}`,
+		`[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+		`{"foo":"bar","baz":"qux"}`},
+	{"A.12 adding to a nonexistent target", `{"foo":"bar"}`,
+		`[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+		``},
+	{"A.13 invalid JSON Patch document", `{"foo":"bar"}`,
+		`[{"op":"add","path":"/baz","value":"qux","op":"remove"}]`,
+		``},
+	{"A.14 ~ escape ordering", `{"/":9,"~1":10}`,
+		`[{"op":"test","path":"/~01","value":10}]`,
+		`{"/":9,"~1":10}`},
+	{"A.15 comparing strings and numbers", `{"/":9,"~1":10}`,
+		`[{"op":"test","path":"/~01","value":"10"}]`,
+		``},
+	{"A.16 adding an array value", `{"foo":["bar"]}`,
+		`[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+		`{"foo":["bar",["abc","def"]]}`},
+	{"test compares numbers by value", `{"n":1.0}`,
+		`[{"op":"test","path":"/n","value":1}]`,
+		`{"n":1.0}`},
+	{"a failed operation leaves nothing applied", `{"a":1}`,
+		`[{"op":"remove","path":"/a"},{"op":"remove","path":"/a"}]`,
+		``},
+}
+
+func TestApply(t *testing.T) {
+	for _, tt := range applyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := decode(t, tt.doc)
+			ops, err := Parse(decode(t, tt.patch))
+			if err == nil {
+				var got interface{}
+				got, err = Apply(doc, ops)
+				if err == nil && tt.want != "" && !tree.Equal(got, decode(t, tt.want)) {
+					t.Errorf("got %v, want %s", got, tt.want)
+				}
+			}
+			if tt.want == "" && err == nil {
+				t.Errorf("applied, want an error")
+			}
+			if tt.want != "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tree.Equal(doc, decode(t, tt.doc)) {
+				t.Errorf("the document was modified: %v", doc)
+			}
+		})
+	}
+}
+
+// the examples of RFC 7396, appendix A
+var mergeTests = []struct {
+	doc, patch, want string
+}{
+	{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+	{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+	{`{"a":"b"}`, `{"a":null}`, `{}`},
+	{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+	{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+	{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+	{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+	{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+	{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+	{`{"a":"b"}`, `["c"]`, `["c"]`},
+	{`{"a":"foo"}`, `null`, `null`},
+	{`{"a":"foo"}`, `"bar"`, `"bar"`},
+	{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+	{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+	{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+}
+
+func TestMerge(t *testing.T) {
+	for _, tt := range mergeTests {
+		doc := decode(t, tt.doc)
+		got := Merge(doc, decode(t, tt.patch))
+		if !tree.Equal(got, decode(t, tt.want)) {
+			t.Errorf("Merge(%s, %s) = %v, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+		if !tree.Equal(doc, decode(t, tt.doc)) {
+			t.Errorf("Merge(%s, %s) modified the document: %v", tt.doc, tt.patch, doc)
+		}
+	}
+}
+
+func TestRename(t *testing.T) {
+	doc := decode(t, `{"a":1,"b":2,"c":3}`)
+	got, err := Rename(doc, "/b", "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := strings.Join(got.(tree.Object).Keys(), ","); keys != "a,x,c" {
+		t.Errorf("keys %s, want a,x,c", keys)
+	}
+	if _, err := Rename(doc, "/b", "c"); err == nil {
+		t.Errorf("renaming onto an existing key succeeded")
+	}
+}
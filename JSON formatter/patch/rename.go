@@ -0,0 +1,36 @@
+package patch
+
+import (
+	"fmt"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// Rename gives the object member ptr points at the name key, keeping its
+// place among the other members, which a JSON Patch move cannot do. Like
+// Apply it works on a copy of doc.
+func Rename(doc interface{}, ptr, key string) (interface{}, error) {
+	path, err := tree.ParsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot rename the whole document")
+	}
+	return update(clone(doc), path, func(parent interface{}, t string) (interface{}, error) {
+		obj, ok := parent.(tree.Object)
+		if !ok {
+			return nil, fmt.Errorf("cannot rename in a %s", kind(parent))
+		}
+		if _, taken := obj.Get(key); taken && key != t {
+			return nil, fmt.Errorf("member %q already exists", key)
+		}
+		for i := range obj {
+			if obj[i].Key == t {
+				obj[i].Key = key
+				return obj, nil
+			}
+		}
+		return nil, fmt.Errorf("no member %q", t)
+	})
+}
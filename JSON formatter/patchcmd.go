@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"example.com/json-view-formatter/decode"
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/patch"
+)
+
+// runPatch applies patch files to a document in order. A patch that is a
+// JSON array is an RFC 6902 JSON Patch, anything else an RFC 7396 merge
+// patch. Nothing is printed or written unless every patch applies.
+func runPatch(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("jsonFormatViewer patch", "document patch...", stderr)
+	merge := flags.Bool("merge", false, "treat every patch as a merge patch, also arrays")
+	write := flags.Bool("w", false, "write the result back to the document instead of printing it")
+	lossy := flags.Bool("lossy", false, lossyUsage)
+	format := flags.String("o", "", "output `format`: tree, "+strings.Join(encode.Formats(), ", ")+"; defaults to tree, or the document's own format with -w")
+	colorMode := flags.String("color", "auto", "color output: auto, always or never; auto honors NO_COLOR")
+	themeName := flags.String("theme", "default", "color theme: "+strings.Join(themeNames(), ", ")+" or a theme file")
+	inputName := flags.String("input", "auto", inputUsage)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return exitUsage
+	}
+	path := flags.Arg(0)
+	if *write && path == stdinName {
+		fmt.Fprintln(stderr, "-w cannot write back to stdin")
+		return exitUsage
+	}
+	input, err := parseInput(*inputName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if input == "" {
+		input = decode.Detect(path)
+	}
+	if *write && !*lossy && dropsLayout(stderr, displayName(path), input) {
+		return exitUsage
+	}
+	if *format == "" {
+		*format = "tree"
+		if *write {
+			*format = outputFormat(input)
+		}
+	}
+	if *write && *format == "tree" {
+		fmt.Fprintln(stderr, "-w needs a data format, not -o tree")
+		return exitUsage
+	}
+	var enc encode.Encoder
+	if *format != "tree" {
+		if enc, err = encode.New(*format, encode.DefaultOptions); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+	}
+	th, err := loadTheme(*themeName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	color, err := useColor(*colorMode, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if !color {
+		th = nil
+	}
+
+	doc, code := loadDocument(stderr, stdin, path, input)
+	if code != exitOK {
+		return code
+	}
+	for _, name := range flags.Args()[1:] {
+		p, code := loadDocument(stderr, stdin, name, "")
+		if code != exitOK {
+			return code
+		}
+		if doc, err = applyPatch(doc, p, *merge); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", displayName(name), err)
+			if errors.Is(err, errBadOp) {
+				return exitInvalid
+			}
+			return exitPatchFailed
+		}
+	}
+
+	if *write {
+		var buf bytes.Buffer
+		if err := enc.Encode(&buf, doc); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			return exitUnsupported
+		}
+		if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+			return reportError(stderr, path, &writeError{err})
+		}
+		return exitOK
+	}
+	out := bufio.NewWriter(stdout)
+	if enc != nil {
+		return writeEncoded(out, stderr, displayName(path), enc, doc)
+	}
+	err = prettyPrint(out, doc, "", th)
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		return reportError(stderr, displayName(path), &writeError{err})
+	}
+	return exitOK
+}
+
+// errBadOp marks patch documents that are malformed, as opposed to
+// operations that do not apply to the document
+var errBadOp = errors.New("malformed patch")
+
+// applyPatch applies one decoded patch file to doc
+func applyPatch(doc, p interface{}, merge bool) (interface{}, error) {
+	if _, isList := p.([]interface{}); merge || !isList {
+		return patch.Merge(doc, p), nil
+	}
+	ops, err := patch.Parse(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errBadOp, err)
+	}
+	return patch.Apply(doc, ops)
+}
+
+// outputFormat is the encoder writing documents of an input format back.
+// JSONC and JSON5 are written as plain JSON, so their comments are lost.
+func outputFormat(f decode.Format) string {
+	switch f {
+	case decode.YAML:
+		return "yaml"
+	case decode.TOML:
+		return "toml"
+	case decode.Gron:
+		return "gron"
+	}
+	return "json"
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the
+// same directory, so readers see the old or the new file but never a
+// partial one. The file keeps its permissions.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
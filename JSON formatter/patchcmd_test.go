@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPatchWrite(t *testing.T) {
+	const addB = `[{"op":"add","path":"/b","value":2}]`
+	tests := []struct {
+		name, file, doc string
+		args            []string
+		code            int
+		want            string // the document afterwards
+	}{
+		{"json", "doc.json", `{"a":1}`, nil, exitOK, "{\n  \"a\": 1,\n  \"b\": 2\n}\n"},
+		{"jsonc needs -lossy", "doc.jsonc", "// c\n{\"a\":1}", nil, exitUsage, "// c\n{\"a\":1}"},
+		{"yaml needs -lossy", "doc.yaml", "# c\na: 1\n", nil, exitUsage, "# c\na: 1\n"},
+		{"toml needs -lossy", "doc.toml", "# c\na = 1\n", nil, exitUsage, "# c\na = 1\n"},
+		{"yaml with -lossy", "doc.yaml", "# c\na: 1\n", []string{"-lossy"}, exitOK, "a: 1\nb: 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.file, tt.doc, "p.json", addB)
+			args := append(append([]string{"patch", "-w"}, tt.args...), filepath.Join(dir, tt.file), filepath.Join(dir, "p.json"))
+			_, errOut, code := runCLI(t, "", args...)
+			if code != tt.code {
+				t.Fatalf("exit %d, want %d; stderr: %s", code, tt.code, errOut)
+			}
+			got, err := os.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchExitCodes(t *testing.T) {
+	tests := []struct {
+		name, patch string
+		code        int
+	}{
+		{"applies", `[{"op":"test","path":"/a","value":1}]`, exitOK},
+		{"merge patch", `{"a":null}`, exitOK},
+		{"failed test", `[{"op":"test","path":"/a","value":2}]`, exitPatchFailed},
+		{"missing path", `[{"op":"remove","path":"/nope"}]`, exitPatchFailed},
+		{"malformed", `[{"op":"frobnicate","path":"/a"}]`, exitInvalid},
+		{"unreadable", "", exitUnreadable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, "doc.json", `{"a":1}`, "p.json", tt.patch)
+			patchFile := filepath.Join(dir, "p.json")
+			if tt.patch == "" {
+				patchFile = filepath.Join(dir, "missing.json")
+			}
+			doc := filepath.Join(dir, "doc.json")
+			_, errOut, code := runCLI(t, "", "patch", "-w", doc, patchFile)
+			if code != tt.code {
+				t.Fatalf("exit %d, want %d; stderr: %s", code, tt.code, errOut)
+			}
+			// a patch that fails leaves the document alone
+			if got, _ := os.ReadFile(doc); code != exitOK && string(got) != `{"a":1}` {
+				t.Errorf("the document became %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package query
+
+import (
+	"encoding/json"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// expr is a node of a filter predicate
+type expr interface {
+	// value returns the operand's value, false when a path matched nothing
+	value(root, cur interface{}) (interface{}, bool)
+	// test evaluates the node as a condition
+	test(root, cur interface{}) bool
+}
+
+// pathExpr is @ or $ followed by path segments
+type pathExpr struct {
+	absolute bool
+	steps    []step
+}
+
+func (e pathExpr) value(root, cur interface{}) (interface{}, bool) {
+	start := cur
+	if e.absolute {
+		start = root
+	}
+	vals := evalSteps(e.steps, root, start)
+	if len(vals) == 0 {
+		return nil, false
+	}
+	return vals[0], true
+}
+
+// a bare path is an existence test
+func (e pathExpr) test(root, cur interface{}) bool {
+	_, ok := e.value(root, cur)
+	return ok
+}
+
+type literalExpr struct {
+	v interface{}
+}
+
+func (e literalExpr) value(root, cur interface{}) (interface{}, bool) { return e.v, true }
+
+// a bare literal is true unless it is false, null, zero or "", as in the
+// JavaScript of the original JSONPath
+func (e literalExpr) test(root, cur interface{}) bool {
+	switch v := e.v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+	c, ok := tree.CompareNumbers(e.v, json.Number("0"))
+	return !ok || c != 0
+}
+
+type cmpExpr struct {
+	op          string
+	left, right expr
+}
+
+func (e cmpExpr) value(root, cur interface{}) (interface{}, bool) {
+	return e.test(root, cur), true
+}
+
+func (e cmpExpr) test(root, cur interface{}) bool {
+	l, lok := e.left.value(root, cur)
+	r, rok := e.right.value(root, cur)
+	if !lok || !rok {
+		// a missing value only differs from everything
+		return e.op == "!=" && lok != rok
+	}
+	switch e.op {
+	case "==":
+		return tree.Equal(l, r)
+	case "!=":
+		return !tree.Equal(l, r)
+	}
+	if c, ok := tree.CompareNumbers(l, r); ok {
+		return compare(e.op, c < 0, c == 0)
+	}
+	x, xok := l.(string)
+	y, yok := r.(string)
+	if xok && yok {
+		return compare(e.op, x < y, x == y)
+	}
+	return false
+}
+
+func compare(op string, less, eq bool) bool {
+	switch op {
+	case "<":
+		return less
+	case "<=":
+		return less || eq
+	case ">":
+		return !less && !eq
+	case ">=":
+		return !less
+	}
+	return false
+}
+
+type notExpr struct {
+	x expr
+}
+
+func (e notExpr) value(root, cur interface{}) (interface{}, bool) { return e.test(root, cur), true }
+func (e notExpr) test(root, cur interface{}) bool                 { return !e.x.test(root, cur) }
+
+type logicExpr struct {
+	and         bool
+	left, right expr
+}
+
+func (e logicExpr) value(root, cur interface{}) (interface{}, bool) { return e.test(root, cur), true }
+
+func (e logicExpr) test(root, cur interface{}) bool {
+	if e.and {
+		return e.left.test(root, cur) && e.right.test(root, cur)
+	}
+	return e.left.test(root, cur) || e.right.test(root, cur)
+}
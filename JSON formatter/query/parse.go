@@ -0,0 +1,429 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parse compiles a path expression
+func Parse(expr string) (*Query, error) {
+	p := &parser{src: expr}
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("empty expression")
+	}
+
+	var steps []step
+	switch {
+	case p.peek() == '$':
+		p.pos++
+	case isNameChar(p.peek()):
+		// a bare leading name as in "shipTo.city"
+		steps = append(steps, step{sel: nameSelector{names: []string{p.name()}}})
+	case p.peek() == '.' && p.pos+1 == len(p.src):
+		// jq's identity
+		p.pos++
+		return &Query{expr: expr}, nil
+	}
+
+	steps, err := p.segments(steps)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+	return &Query{expr: expr, steps: steps}, nil
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Expr: p.src, Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *parser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return r
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// consume skips s if the input continues with it
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) error {
+	p.skipSpace()
+	if !p.consume(s) {
+		if p.eof() {
+			return p.errorf("expected %q, found end of expression", s)
+		}
+		return p.errorf("expected %q, found %q", s, p.peek())
+	}
+	return nil
+}
+
+func isNameChar(r rune) bool {
+	return r == '_' || r == '-' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (p *parser) name() string {
+	start := p.pos
+	for !p.eof() && isNameChar(p.peek()) {
+		_, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.pos += size
+	}
+	return p.src[start:p.pos]
+}
+
+// segments parses ".name", ".*", "..x" and "[...]" segments until
+// something else comes up
+func (p *parser) segments(steps []step) ([]step, error) {
+	for !p.eof() {
+		var (
+			descend bool
+			sel     selector
+			err     error
+		)
+		switch {
+		case p.consume(".."):
+			descend = true
+			if p.peek() == '[' {
+				sel, err = p.bracket()
+			} else {
+				sel, err = p.member()
+			}
+		case p.consume("."):
+			sel, err = p.member()
+		case p.peek() == '[':
+			sel, err = p.bracket()
+		default:
+			return steps, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step{descend: descend, sel: sel})
+	}
+	return steps, nil
+}
+
+// member parses what follows a dot: a field name or '*'
+func (p *parser) member() (selector, error) {
+	switch {
+	case p.consume("*"):
+		return wildcardSelector{}, nil
+	case isNameChar(p.peek()):
+		return nameSelector{names: []string{p.name()}}, nil
+	}
+	return nil, p.errorf("expected field name or '*'")
+}
+
+// bracket parses "[...]": wildcard, names, indices, slice or filter
+func (p *parser) bracket() (selector, error) {
+	p.pos++ // '['
+	p.skipSpace()
+
+	var sel selector
+	switch c := p.peek(); {
+	case c == ']':
+		// jq's iterator, items[]
+		sel = wildcardSelector{}
+	case c == '*':
+		p.pos++
+		sel = wildcardSelector{}
+	case c == '?':
+		p.pos++
+		// the parentheses of ?(...) are an ordinary group, so that
+		// ?(@.a) && (@.b) parses as two groups rather than one
+		pred, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		sel = filterSelector{pred: pred}
+	case c == '\'' || c == '"':
+		var names []string
+		for {
+			name, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+			p.skipSpace()
+			if !p.consume(",") {
+				break
+			}
+			p.skipSpace()
+		}
+		sel = nameSelector{names: names}
+	default:
+		var err error
+		if sel, err = p.indexOrSlice(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
+
+func (p *parser) indexOrSlice() (selector, error) {
+	first, ok, err := p.optInt()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.consume(":") {
+		s := sliceSelector{step: 1}
+		if ok {
+			s.start = &first
+		}
+		end, ok, err := p.optInt()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			s.end = &end
+		}
+		p.skipSpace()
+		if p.consume(":") {
+			st, ok, err := p.optInt()
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				if st == 0 {
+					return nil, p.errorf("slice step cannot be zero")
+				}
+				s.step = st
+			}
+		}
+		return s, nil
+	}
+	if !ok {
+		return nil, p.errorf("expected index, slice, name, '*' or filter")
+	}
+
+	indices := []int{first}
+	for p.skipSpace(); p.consume(","); p.skipSpace() {
+		i, ok, err := p.optInt()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, p.errorf("expected index after ','")
+		}
+		indices = append(indices, i)
+	}
+	return indexSelector{indices: indices}, nil
+}
+
+// optInt parses an integer if one comes next
+func (p *parser) optInt() (int, bool, error) {
+	p.skipSpace()
+	start := p.pos
+	p.consume("-")
+	for !p.eof() && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, false, nil
+	}
+	lit := p.src[start:p.pos]
+	i, err := strconv.Atoi(lit)
+	if err != nil {
+		p.pos = start
+		return 0, false, p.errorf("bad integer %q", lit)
+	}
+	return i, true, nil
+}
+
+// quoted parses a single or double quoted string with backslash escapes:
+// those of Go string literals, which Join writes, and a backslash before
+// any other character standing for that character
+func (p *parser) quoted() (string, error) {
+	start := p.pos
+	quote := p.src[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			if p.eof() {
+				break
+			}
+			if r, multibyte, tail, err := strconv.UnquoteChar(p.src[p.pos-1:], quote); err == nil {
+				// \x escapes are bytes, not runes
+				if multibyte || r < utf8.RuneSelf {
+					sb.WriteRune(r)
+				} else {
+					sb.WriteByte(byte(r))
+				}
+				p.pos = len(p.src) - len(tail)
+				continue
+			}
+			sb.WriteByte(p.src[p.pos])
+			p.pos++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+// filter grammar:
+//
+//	or      = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | "(" or ")" | operand [ cmpop operand ]
+//	operand = "@" segments | "$" segments | string | number | true | false | null
+
+func (p *parser) or() (expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpace(); p.consume("||"); p.skipSpace() {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = logicExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.skipSpace(); p.consume("&&"); p.skipSpace() {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = logicExpr{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+var cmpOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func (p *parser) unary() (expr, error) {
+	p.skipSpace()
+	if !strings.HasPrefix(p.src[p.pos:], "!=") && p.consume("!") {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x: x}, nil
+	}
+	if p.consume("(") {
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return x, nil
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	for _, op := range cmpOps {
+		if p.consume(op) {
+			right, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			return cmpExpr{op: op, left: left, right: right}, nil
+		}
+	}
+	if p.consume("=") {
+		p.pos--
+		return nil, p.errorf("'=' is not an operator, use '=='")
+	}
+	return left, nil
+}
+
+func (p *parser) operand() (expr, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("expected value, found end of expression")
+	}
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		steps, err := p.segments(nil)
+		if err != nil {
+			return nil, err
+		}
+		return pathExpr{absolute: c == '$', steps: steps}, nil
+	case c == '\'' || c == '"':
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return literalExpr{v: s}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.number()
+	}
+	for _, kw := range []struct {
+		word string
+		v    interface{}
+	}{{"true", true}, {"false", false}, {"null", nil}} {
+		if p.consume(kw.word) {
+			return literalExpr{v: kw.v}, nil
+		}
+	}
+	return nil, p.errorf("expected value, found %q", p.peek())
+}
+
+func (p *parser) number() (expr, error) {
+	start := p.pos
+	p.consume("-")
+	for !p.eof() && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	lit := p.src[start:p.pos]
+	if _, err := strconv.ParseFloat(lit, 64); err != nil {
+		p.pos = start
+		return nil, p.errorf("bad number")
+	}
+	return literalExpr{v: json.Number(lit)}, nil
+}
@@ -0,0 +1,207 @@
+// Package query evaluates a subset of JSONPath and jq path syntax against
+// the viewer's value tree.
+//
+// Supported forms, with or without the leading "$":
+//
+//	shipTo.city            field access (also .shipTo.city and $.shipTo.city)
+//	['ship to']            quoted field names
+//	items[0], items[-1]    array index, negative counts from the end
+//	items[1:3], items[::2] array slice
+//	items[*], shipTo.*     wildcards (items[] as in jq works too)
+//	..price, $..[0]        recursive descent
+//	items[?(@.price > 20)] filter predicates with == != < <= > >=, && || !
+//
+// Objects may be tree.Object or map[string]interface{}; arrays are
+// []interface{}.
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// Query is a parsed path expression, safe for concurrent use
+type Query struct {
+	expr  string
+	steps []step
+}
+
+// step applies one selector to every current node, or to every current
+// node and all of its descendants when descend is set
+type step struct {
+	descend bool
+	sel     selector
+}
+
+// SyntaxError describes why an expression could not be parsed
+type SyntaxError struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("query %q: %s at position %d", e.Expr, e.Msg, e.Pos+1)
+}
+
+// MustParse is Parse that panics on error, for expressions fixed at
+// compile time
+func MustParse(expr string) *Query {
+	q, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// String returns the expression the query was parsed from
+func (q *Query) String() string { return q.expr }
+
+// Definite reports whether the query can match at most one value, i.e. it
+// only uses field names and single indices
+func (q *Query) Definite() bool {
+	for _, s := range q.steps {
+		if s.descend || !s.sel.definite() {
+			return false
+		}
+	}
+	return true
+}
+
+// Tokens returns the JSON Pointer reference tokens of the place a
+// definite query names, which need not exist yet. Negative indexes are
+// resolved against the arrays in doc.
+func (q *Query) Tokens(doc interface{}) ([]string, error) {
+	if !q.Definite() {
+		return nil, fmt.Errorf("query %q can match more than one value", q.expr)
+	}
+	var tokens []string
+	cur := doc
+	for _, s := range q.steps {
+		switch sel := s.sel.(type) {
+		case nameSelector:
+			tokens = append(tokens, sel.names[0])
+			cur, _ = field(cur, sel.names[0])
+		case indexSelector:
+			arr, _ := cur.([]interface{})
+			i := sel.indices[0]
+			if i < 0 {
+				if i += len(arr); i < 0 {
+					return nil, fmt.Errorf("query %q: index %d is out of range", q.expr, sel.indices[0])
+				}
+			}
+			tokens = append(tokens, strconv.Itoa(i))
+			cur = nil
+			if i < len(arr) {
+				cur = arr[i]
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// Eval returns every value in doc matched by the query, in document order
+func (q *Query) Eval(doc interface{}) []interface{} {
+	return evalSteps(q.steps, doc, doc)
+}
+
+func evalSteps(steps []step, root, v interface{}) []interface{} {
+	cur := []interface{}{v}
+	for _, s := range steps {
+		var next []interface{}
+		for _, node := range cur {
+			if !s.descend {
+				next = s.sel.apply(root, node, next)
+				continue
+			}
+			walk(node, func(n interface{}) {
+				next = s.sel.apply(root, n, next)
+			})
+		}
+		cur = next
+	}
+	return cur
+}
+
+// walk calls fn for v and then for each of its descendants, depth first
+func walk(v interface{}, fn func(interface{})) {
+	fn(v)
+	switch c := v.(type) {
+	case tree.Object:
+		for _, m := range c {
+			walk(m.Value, fn)
+		}
+	case map[string]interface{}:
+		for _, k := range sortedKeys(c) {
+			walk(c[k], fn)
+		}
+	case []interface{}:
+		for _, item := range c {
+			walk(item, fn)
+		}
+	}
+}
+
+// members returns the values of an object in iteration order, or false
+// when v is not an object
+func members(v interface{}) ([]interface{}, bool) {
+	switch c := v.(type) {
+	case tree.Object:
+		vals := make([]interface{}, len(c))
+		for i, m := range c {
+			vals[i] = m.Value
+		}
+		return vals, true
+	case map[string]interface{}:
+		keys := sortedKeys(c)
+		vals := make([]interface{}, len(keys))
+		for i, k := range keys {
+			vals[i] = c[k]
+		}
+		return vals, true
+	}
+	return nil, false
+}
+
+func field(v interface{}, name string) (interface{}, bool) {
+	switch c := v.(type) {
+	case tree.Object:
+		return c.Get(name)
+	case map[string]interface{}:
+		val, ok := c[name]
+		return val, ok
+	}
+	return nil, false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Join appends an object key to a path in query syntax
+func Join(path, key string) string {
+	if isIdent(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+// isIdent reports whether key can follow a dot in a path
+func isIdent(key string) bool {
+	if key == "" || !utf8.ValidString(key) {
+		return false
+	}
+	return strings.IndexFunc(key, func(r rune) bool {
+		return !(r == '_' || r == '-' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r > 0x7f)
+	}) < 0
+}
@@ -0,0 +1,181 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/tree"
+)
+
+const store = `{
+	"shipTo": {"name": "Jane", "city": "Pretendville"},
+	"items": [
+		{"sku": "a-1", "price": 23.95, "qty": 2, "tags": ["new"]},
+		{"sku": "b-2", "price": 5, "qty": 0},
+		{"sku": "c-3", "price": 120.5, "qty": 1, "gift": true}
+	],
+	"ship to": "quoted",
+	"total": 172.4
+}`
+
+// show formats the values a query found: objects by their keys, arrays
+// by their length and scalars as they print
+func show(vals []interface{}) string {
+	parts := make([]string, len(vals))
+	for i, v := range vals {
+		switch x := v.(type) {
+		case tree.Object:
+			parts[i] = "{" + strings.Join(x.Keys(), ",") + "}"
+		case []interface{}:
+			parts[i] = fmt.Sprintf("[%d]", len(x))
+		default:
+			parts[i] = fmt.Sprint(x)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+var evalTests = []struct {
+	expr, want string
+}{
+	{"shipTo.city", "Pretendville"},
+	{".shipTo.city", "Pretendville"},
+	{"$.shipTo.city", "Pretendville"},
+	{"$", "{shipTo,items,ship to,total}"},
+	{"['ship to']", "quoted"},
+	{`["ship to"]`, "quoted"},
+	{"$['shipTo','total']", "{name,city} 172.4"},
+	{"items[0].sku", "a-1"},
+	{"items[-1].sku", "c-3"},
+	{"items[5]", ""},
+	{"items[1:].sku", "b-2 c-3"},
+	{"items[::2].sku", "a-1 c-3"},
+	{"items[*].qty", "2 0 1"},
+	{"items[].qty", "2 0 1"},
+	{"shipTo.*", "Jane Pretendville"},
+	{"..sku", "a-1 b-2 c-3"},
+	{"$..tags[0]", "new"},
+	{"missing.path", ""},
+
+	{"items[?(@.price > 20)].sku", "a-1 c-3"},
+	{"items[?@.price > 20].sku", "a-1 c-3"},
+	{"items[?(@.price == 5.0)].sku", "b-2"},
+	{"items[?(@.sku != 'b-2')].sku", "a-1 c-3"},
+	{"items[?(@.sku >= 'b')].sku", "b-2 c-3"},
+	{"items[?(@.gift)].sku", "c-3"},
+	{"items[?(!@.gift)].sku", "a-1 b-2"},
+	{"items[?(@.gift != true)].sku", "a-1 b-2"},
+	{"items[?(@.price > 20 && @.qty < 2)].sku", "c-3"},
+	{"items[?(@.price > 20) && (@.qty < 2)].sku", "c-3"},
+	{"items[?(@.qty == 0) || (@.gift)].sku", "b-2 c-3"},
+	{"items[?(!(@.price > 20) || @.gift)].sku", "b-2 c-3"},
+	{"items[?(@.price > $.items[1].price)].sku", "a-1 c-3"},
+
+	// bare literals are true unless false, null, zero or ""
+	{"items[?(true)].sku", "a-1 b-2 c-3"},
+	{"items[?(false)].sku", ""},
+	{"items[?(null)].sku", ""},
+	{"items[?(0)].sku", ""},
+	{"items[?(0.0)].sku", ""},
+	{"items[?(1)].sku", "a-1 b-2 c-3"},
+	{"items[?('')].sku", ""},
+	{"items[?('x')].sku", "a-1 b-2 c-3"},
+}
+
+func TestEval(t *testing.T) {
+	doc, err := tree.Decode(strings.NewReader(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range evalTests {
+		q, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := show(q.Eval(doc)); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr, want string
+	}{
+		{"items[", ""},
+		{"items[?(@.a > 1]", `expected ")"`},
+		{"items[?((@.a > 1)]", `expected ")"`},
+		{"items[?(@.a = 1)]", "'=' is not an operator"},
+		{"items[?(@.a > )]", "expected value"},
+		{"['unterminated]", "unterminated string"},
+		{"items[1:2:3:4]", ""},
+		{"items[-]", `bad integer "-" at position 7`},
+		{"items[99999999999999999999]", `bad integer "99999999999999999999"`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", tt.expr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q): %v, want it to mention %s", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	doc, err := tree.Decode(strings.NewReader(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expr, want string
+	}{
+		{"shipTo.city", "/shipTo/city"},
+		{"items[-1].sku", "/items/2/sku"},
+		{"['ship to']", "/ship to"},
+		{"new.key", "/new/key"},
+	}
+	for _, tt := range tests {
+		tokens, err := MustParse(tt.expr).Tokens(doc)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := tree.Pointer(tokens...); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+	for _, expr := range []string{"items[*]", "..sku", "items[-9]"} {
+		if _, err := MustParse(expr).Tokens(doc); err == nil {
+			t.Errorf("%s: Tokens succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct{ key, want string }{
+		{"city", "$.city"},
+		{"ship-to", "$.ship-to"},
+		{"a b", `$["a b"]`},
+		{"", `$[""]`},
+		{`q"`, `$["q\""]`},
+		{"a\x01\x7f\u00e9\n", `$["a\x01\x7fé\n"]`},
+		{"\xff", `$["\xff"]`},
+		{`'`, `$["'"]`},
+	}
+	for _, tt := range tests {
+		got := Join("$", tt.key)
+		if got != tt.want {
+			t.Errorf("Join(%q) = %s, want %s", tt.key, got, tt.want)
+		}
+		// the path reads back to the member
+		doc := tree.Object{{Key: tt.key, Value: "v"}}
+		if vals := MustParse(got).Eval(doc); len(vals) != 1 || vals[0] != "v" {
+			t.Errorf("%s matched %v", got, vals)
+		}
+	}
+}
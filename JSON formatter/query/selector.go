@@ -0,0 +1,134 @@
+package query
+
+// selector picks values out of a single node and appends them to out
+type selector interface {
+	apply(root, v interface{}, out []interface{}) []interface{}
+	definite() bool
+}
+
+// nameSelector picks object members by name
+type nameSelector struct {
+	names []string
+}
+
+func (s nameSelector) apply(root, v interface{}, out []interface{}) []interface{} {
+	for _, name := range s.names {
+		if val, ok := field(v, name); ok {
+			out = append(out, val)
+		}
+	}
+	return out
+}
+
+func (s nameSelector) definite() bool { return len(s.names) == 1 }
+
+// wildcardSelector picks every member of an object or element of an array
+type wildcardSelector struct{}
+
+func (wildcardSelector) apply(root, v interface{}, out []interface{}) []interface{} {
+	if arr, ok := v.([]interface{}); ok {
+		return append(out, arr...)
+	}
+	if vals, ok := members(v); ok {
+		return append(out, vals...)
+	}
+	return out
+}
+
+func (wildcardSelector) definite() bool { return false }
+
+// indexSelector picks array elements by position, negative counting from
+// the end
+type indexSelector struct {
+	indices []int
+}
+
+func (s indexSelector) apply(root, v interface{}, out []interface{}) []interface{} {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return out
+	}
+	for _, i := range s.indices {
+		if i < 0 {
+			i += len(arr)
+		}
+		if i >= 0 && i < len(arr) {
+			out = append(out, arr[i])
+		}
+	}
+	return out
+}
+
+func (s indexSelector) definite() bool { return len(s.indices) == 1 }
+
+// sliceSelector picks a range of array elements with Python slice
+// semantics
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) apply(root, v interface{}, out []interface{}) []interface{} {
+	arr, ok := v.([]interface{})
+	if !ok || s.step == 0 {
+		return out
+	}
+	n := len(arr)
+	bound := func(p *int, def int) int {
+		if p == nil {
+			return def
+		}
+		i := *p
+		if i < 0 {
+			i += n
+		}
+		return i
+	}
+	if s.step > 0 {
+		start, end := clamp(bound(s.start, 0), 0, n), clamp(bound(s.end, n), 0, n)
+		for i := start; i < end; i += s.step {
+			out = append(out, arr[i])
+		}
+		return out
+	}
+	start, end := clamp(bound(s.start, n-1), -1, n-1), clamp(bound(s.end, -n-1), -1, n-1)
+	for i := start; i > end; i += s.step {
+		out = append(out, arr[i])
+	}
+	return out
+}
+
+func (sliceSelector) definite() bool { return false }
+
+func clamp(i, lo, hi int) int {
+	if i < lo {
+		return lo
+	}
+	if i > hi {
+		return hi
+	}
+	return i
+}
+
+// filterSelector picks the members or elements for which the predicate
+// holds
+type filterSelector struct {
+	pred expr
+}
+
+func (s filterSelector) apply(root, v interface{}, out []interface{}) []interface{} {
+	candidates, ok := v.([]interface{})
+	if !ok {
+		if candidates, ok = members(v); !ok {
+			return out
+		}
+	}
+	for _, c := range candidates {
+		if s.pred.test(root, c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (filterSelector) definite() bool { return false }
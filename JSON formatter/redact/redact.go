@@ -0,0 +1,144 @@
+// Package redact masks secrets in a value tree before it is displayed:
+// members whose key matches a pattern such as *password*, and values that
+// look like credentials wherever they appear.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"example.com/json-view-formatter/query"
+	"example.com/json-view-formatter/tree"
+)
+
+// Marker replaces what is redacted
+const Marker = "[REDACTED]"
+
+// DefaultKeys are the key patterns used when none are configured
+var DefaultKeys = []string{
+	"*password*", "*passwd*", "*secret*", "token", "*_token", "*apikey*",
+	"*api_key*", "*private_key*", "authorization", "*credential*",
+}
+
+// detector finds credentials inside string values. With a capture group
+// only the group is masked, so a URL keeps everything but its password.
+type detector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var detectors = []detector{
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{4,}\.eyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]*`)},
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`)},
+	{"URL credentials", regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]*:([^/\s@]+)@`)},
+}
+
+// Finding is one redacted value and why it was redacted
+type Finding struct {
+	Path   string
+	Reason string
+}
+
+func (f Finding) String() string {
+	return f.Path + " (" + f.Reason + ")"
+}
+
+// Redactor masks the members matching its key patterns and the strings
+// the detectors recognize
+type Redactor struct {
+	keys []keyPattern
+}
+
+type keyPattern struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+// New returns a redactor for case-insensitive key patterns where * is any
+// run of characters and ? any one character
+func New(keys []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, k := range keys {
+		k = strings.TrimSpace(k)
+		if k == "" {
+			continue
+		}
+		expr := regexp.QuoteMeta(k)
+		expr = strings.ReplaceAll(expr, `\*`, ".*")
+		expr = strings.ReplaceAll(expr, `\?`, ".")
+		re, err := regexp.Compile("(?is)^" + expr + "$")
+		if err != nil {
+			return nil, fmt.Errorf("bad key pattern %q: %v", k, err)
+		}
+		r.keys = append(r.keys, keyPattern{glob: k, re: re})
+	}
+	return r, nil
+}
+
+// Redact masks doc in place and returns it with what was redacted, in
+// document order
+func (r *Redactor) Redact(doc interface{}) (interface{}, []Finding) {
+	var found []Finding
+	doc = r.value(doc, "$", &found)
+	return doc, found
+}
+
+func (r *Redactor) value(v interface{}, path string, found *[]Finding) interface{} {
+	switch x := v.(type) {
+	case tree.Object:
+		for i, m := range x {
+			p := query.Join(path, m.Key)
+			if glob, ok := r.matchKey(m.Key); ok {
+				if m.Value != nil {
+					x[i].Value = Marker
+					*found = append(*found, Finding{Path: p, Reason: "key matches " + glob})
+				}
+				continue
+			}
+			x[i].Value = r.value(m.Value, p, found)
+		}
+	case []interface{}:
+		for i, e := range x {
+			x[i] = r.value(e, path+"["+strconv.Itoa(i)+"]", found)
+		}
+	case string:
+		return detect(x, path, found)
+	}
+	return v
+}
+
+func (r *Redactor) matchKey(key string) (string, bool) {
+	for _, k := range r.keys {
+		if k.re.MatchString(key) {
+			return k.glob, true
+		}
+	}
+	return "", false
+}
+
+// detect masks the credentials found in s
+func detect(s, path string, found *[]Finding) string {
+	for _, d := range detectors {
+		locs := d.re.FindAllStringSubmatchIndex(s, -1)
+		if locs == nil {
+			continue
+		}
+		var sb strings.Builder
+		last := 0
+		for _, loc := range locs {
+			start, end := loc[0], loc[1]
+			if len(loc) > 2 {
+				start, end = loc[2], loc[3]
+			}
+			sb.WriteString(s[last:start])
+			sb.WriteString(Marker)
+			last = end
+		}
+		sb.WriteString(s[last:])
+		s = sb.String()
+		*found = append(*found, Finding{Path: path, Reason: d.name})
+	}
+	return s
+}
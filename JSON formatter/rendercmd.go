@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/query"
+	"example.com/json-view-formatter/tree"
+)
+
+// runRender executes a text/template with a document as its data. Objects
+// become maps, so .shipTo.city works and a missing key is an error naming
+// the template line and the key. Numbers print as they are written in the
+// document; eq, ne, lt, le, gt and ge compare them by value with each
+// other and with template constants.
+func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := newFlagSet("jsonFormatViewer render", "[file|-]", stderr)
+	tmplPath := flags.String("template", "", "the text/template `file` to execute")
+	inputName := flags.String("input", "auto", inputUsage)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if *tmplPath == "" || flags.NArg() > 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	input, err := parseInput(*inputName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	src, err := os.ReadFile(*tmplPath)
+	if err != nil {
+		return reportError(stderr, *tmplPath, err)
+	}
+	path := defaultConfigPath
+	if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+	doc, code := loadDocument(stderr, stdin, path, input)
+	if code != exitOK {
+		return code
+	}
+	td := newTemplateData(doc)
+	data := td.value(doc)
+
+	tmpl, err := template.New(*tmplPath).Option("missingkey=error").Funcs(td.funcs()).Parse(string(src))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	out := bufio.NewWriter(stdout)
+	if err := tmpl.Execute(out, data); err != nil {
+		// what was rendered before the error is kept, like a shell
+		// script's partial output
+		out.Flush()
+		fmt.Fprintln(stderr, err)
+		var nm *noMatchError
+		// text/template has no error value for a missing map key
+		if errors.As(err, &nm) || strings.Contains(err.Error(), "map has no entry for key") {
+			return exitNoMatch
+		}
+		return exitUsage
+	}
+	if err := out.Flush(); err != nil {
+		return reportError(stderr, "render", &writeError{err})
+	}
+	return exitOK
+}
+
+// noMatchError is the error of the path function for a path with no value
+type noMatchError struct {
+	expr string
+}
+
+func (e *noMatchError) Error() string { return "path " + e.expr + " matched nothing" }
+
+// templateKey identifies a map or slice handed to a template
+type templateKey struct {
+	ptr uintptr
+	len int
+}
+
+// templateData is the boundary between the document and a template.
+// Templates see objects as maps, which is what makes .shipTo.city work;
+// the functions work on the document itself, looked up again for the
+// maps and slices a template hands them, so queries, toJson and toYaml
+// see tree.Object values in document order.
+type templateData struct {
+	root interface{}
+	// views maps the document's objects and arrays to what templates
+	// see, origins the other way round
+	views   map[templateKey]interface{}
+	origins map[templateKey]interface{}
+}
+
+func newTemplateData(doc interface{}) *templateData {
+	return &templateData{root: doc, views: map[templateKey]interface{}{}, origins: map[templateKey]interface{}{}}
+}
+
+// funcs is the function library of render templates. Lookups take a path
+// in query syntax and an optional value to look in, the document by
+// default, so both {{path "shipTo.city"}} and {{.shipTo | path "city"}}
+// work.
+func (td *templateData) funcs() template.FuncMap {
+	lookup := func(expr string, in []interface{}) ([]interface{}, error) {
+		q, err := query.Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+		v := td.root
+		if len(in) > 0 {
+			v = td.tree(in[0])
+		}
+		return q.Eval(v), nil
+	}
+	return template.FuncMap{
+		// path is the value at expr, an error naming expr when there is none
+		"path": func(expr string, in ...interface{}) (interface{}, error) {
+			found, err := lookup(expr, in)
+			if err != nil {
+				return nil, err
+			}
+			if len(found) == 0 {
+				return nil, &noMatchError{expr}
+			}
+			return td.value(found[0]), nil
+		},
+		// get is path for optional values, nil when there is none
+		"get": func(expr string, in ...interface{}) (interface{}, error) {
+			found, err := lookup(expr, in)
+			if err != nil || len(found) == 0 {
+				return nil, err
+			}
+			return td.value(found[0]), nil
+		},
+		// default is v, or def when v is nil or an empty string
+		"default": func(def, v interface{}) interface{} {
+			if v == nil || v == "" {
+				return def
+			}
+			return v
+		},
+		"toJson": func(v interface{}) (string, error) { return td.encode("compact", v) },
+		"toYaml": func(v interface{}) (string, error) { return td.encode("yaml", v) },
+		"join": func(sep string, list interface{}) (string, error) {
+			items, ok := list.([]interface{})
+			if !ok {
+				return "", fmt.Errorf("join: want an array, got %T", list)
+			}
+			parts := make([]string, len(items))
+			for i, item := range items {
+				parts[i] = td.scalarString(item)
+			}
+			return strings.Join(parts, sep), nil
+		},
+		"split": func(sep, s string) []interface{} {
+			parts := strings.Split(s, sep)
+			list := make([]interface{}, len(parts))
+			for i, p := range parts {
+				list[i] = p
+			}
+			return list
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"toEnv": td.toEnv,
+		"eq":    templateEqual(true),
+		"ne":    templateEqual(false),
+		"lt":    templateOrder(func(c int) bool { return c < 0 }),
+		"le":    templateOrder(func(c int) bool { return c <= 0 }),
+		"gt":    templateOrder(func(c int) bool { return c > 0 }),
+		"ge":    templateOrder(func(c int) bool { return c >= 0 }),
+	}
+}
+
+// templateEqual replaces the eq and ne builtins, which reject numbers of
+// different types, with versions comparing numbers by value. Like the
+// builtin eq, it is true when a equals any of the rest.
+func templateEqual(want bool) func(a interface{}, rest ...interface{}) (bool, error) {
+	return func(a interface{}, rest ...interface{}) (bool, error) {
+		if len(rest) == 0 {
+			return false, errors.New("missing argument for comparison")
+		}
+		for _, b := range rest {
+			eq, err := templateEq(a, b)
+			if err != nil {
+				return false, err
+			}
+			if eq {
+				return want, nil
+			}
+		}
+		return !want, nil
+	}
+}
+
+func templateEq(a, b interface{}) (bool, error) {
+	if c, ok := tree.CompareNumbers(a, b); ok {
+		return c == 0, nil
+	}
+	if a == nil || b == nil {
+		return a == b, nil
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false, fmt.Errorf("incompatible types for comparison: %T and %T", a, b)
+	}
+	if !ta.Comparable() {
+		return false, fmt.Errorf("uncomparable type %T", a)
+	}
+	return a == b, nil
+}
+
+// templateOrder replaces lt, le, gt and ge: numbers compare by value,
+// strings by their bytes
+func templateOrder(holds func(c int) bool) func(a, b interface{}) (bool, error) {
+	return func(a, b interface{}) (bool, error) {
+		if c, ok := tree.CompareNumbers(a, b); ok {
+			return holds(c), nil
+		}
+		x, xok := a.(string)
+		y, yok := b.(string)
+		if !xok || !yok {
+			return false, fmt.Errorf("incompatible types for comparison: %T and %T", a, b)
+		}
+		return holds(strings.Compare(x, y)), nil
+	}
+}
+
+// value converts document values for templates: objects to maps and
+// integers to int64 when that prints them as written, so printf "%d"
+// works. Other numbers stay json.Number, which prints its source text:
+// 1.50 and not 1.5. A value converted before gives the same map again.
+func (td *templateData) value(v interface{}) interface{} {
+	switch x := v.(type) {
+	case tree.Object:
+		if view, ok := td.views[keyOf(x)]; ok && len(x) > 0 {
+			return view
+		}
+		m := make(map[string]interface{}, len(x))
+		for _, member := range x {
+			m[member.Key] = td.value(member.Value)
+		}
+		td.remember(x, m)
+		return m
+	case []interface{}:
+		if view, ok := td.views[keyOf(x)]; ok && len(x) > 0 {
+			return view
+		}
+		list := make([]interface{}, len(x))
+		for i, e := range x {
+			list[i] = td.value(e)
+		}
+		if len(list) > 0 {
+			td.remember(x, list)
+		}
+		return list
+	case json.Number:
+		if n, err := x.Int64(); err == nil && strconv.FormatInt(n, 10) == string(x) {
+			return n
+		}
+	}
+	return v
+}
+
+func (td *templateData) remember(doc, view interface{}) {
+	td.views[keyOf(doc)] = view
+	td.origins[keyOf(view)] = doc
+}
+
+// keyOf identifies a map or slice by where its contents live
+func keyOf(v interface{}) templateKey {
+	rv := reflect.ValueOf(v)
+	return templateKey{rv.Pointer(), rv.Len()}
+}
+
+// tree turns a template value back into a document value: the document's
+// own object or array where it came from the document, otherwise a
+// converted copy with map keys sorted, and json.Number for integers
+func (td *templateData) tree(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if orig, ok := td.origins[keyOf(x)]; ok {
+			return orig
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		obj := make(tree.Object, len(keys))
+		for i, k := range keys {
+			obj[i] = tree.Member{Key: k, Value: td.tree(x[k])}
+		}
+		return obj
+	case []interface{}:
+		if orig, ok := td.origins[keyOf(x)]; ok && len(x) > 0 {
+			return orig
+		}
+		list := make([]interface{}, len(x))
+		for i, e := range x {
+			list[i] = td.tree(e)
+		}
+		return list
+	case int64:
+		return json.Number(strconv.FormatInt(x, 10))
+	case int:
+		return json.Number(strconv.Itoa(x))
+	}
+	return v
+}
+
+// encode writes a template value in an output format without the final
+// newline
+func (td *templateData) encode(format string, v interface{}) (string, error) {
+	var sb strings.Builder
+	enc, _ := encode.New(format, encode.DefaultOptions)
+	if err := enc.Encode(&sb, td.tree(v)); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// toEnv flattens a value to KEY=value lines, keys being the prefix and
+// the upper-cased path joined by underscores, so {"shipTo":{"city":...}}
+// with prefix APP_ gives APP_SHIPTO_CITY, the variable the config
+// subcommand reads back. Values that need it are double-quoted.
+func (td *templateData) toEnv(prefix string, v interface{}) string {
+	var lines []string
+	var walk func(name string, v interface{})
+	walk = func(name string, v interface{}) {
+		switch x := v.(type) {
+		case tree.Object:
+			for _, m := range x {
+				walk(envName(name, m.Key), m.Value)
+			}
+		case []interface{}:
+			for i, e := range x {
+				walk(envName(name, strconv.Itoa(i)), e)
+			}
+		default:
+			lines = append(lines, name+"="+envQuote(td.scalarString(v)))
+		}
+	}
+	walk(strings.TrimSuffix(prefix, "_"), td.tree(v))
+	return strings.Join(lines, "\n")
+}
+
+func envName(prefix, key string) string {
+	key = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(key))
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+// envQuote double-quotes a value unless it only has characters that need
+// no quoting in an env file or a shell
+func envQuote(s string) string {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("_-.,:/@+%", r)) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// scalarString formats a value for text output: strings as they are, null
+// as an empty string and containers as JSON
+func (td *templateData) scalarString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case map[string]interface{}, []interface{}:
+		s, _ := td.encode("compact", x)
+		return s
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	const doc = `{
+		"name": "shop", "price": 1.50, "count": 3,
+		"pairs": [
+			{"x": {"k": 1}, "y": {"k": 2}},
+			{"x": {"k": 1, "z": [1]}, "y": {"z": [1.0], "k": 1}}
+		],
+		"shipTo": {"zip": "123", "city": "Pretendville"}
+	}`
+	tests := []struct {
+		name, tmpl, want string
+		code             int
+	}{
+		{"fields", `{{.name}} {{.shipTo.city}}`, "shop Pretendville", exitOK},
+		{"numbers as written", `{{.price}} {{printf "%d" .count}}`, "1.50 3", exitOK},
+		{"numbers compare by value", `{{if eq .price 1.5}}yes{{end}} {{if gt .count 2.5}}yes{{end}}`, "yes yes", exitOK},
+		{"filter comparing objects", `{{path "pairs[?(@.x == @.y)]" | toJson}}`, `{"x":{"k":1,"z":[1]},"y":{"z":[1.0],"k":1}}`, exitOK},
+		{"path on a template value", `{{.shipTo | path "city"}}`, "Pretendville", exitOK},
+		{"get of a missing path", `{{get "nope" | default "none"}}`, "none", exitOK},
+		{"toJson keeps member order", `{{toJson .shipTo}}`, `{"zip":"123","city":"Pretendville"}`, exitOK},
+		{"toEnv", `{{toEnv "APP_" .shipTo}}`, "APP_ZIP=123\nAPP_CITY=Pretendville", exitOK},
+		{"join", `{{join "," (split "-" "a-b-c")}}`, "a,b,c", exitOK},
+		{"missing key", `{{.nope}}`, "", exitNoMatch},
+		{"missing path", `{{path "nope"}}`, "", exitNoMatch},
+		{"other execution errors", `{{index .count 1}}`, "", exitUsage},
+		{"parse errors", `{{.name`, "", exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, "doc.json", doc, "t.tmpl", tt.tmpl)
+			out, errOut, code := runCLI(t, "", "render", "-template", filepath.Join(dir, "t.tmpl"), filepath.Join(dir, "doc.json"))
+			if code != tt.code {
+				t.Fatalf("exit %d, want %d; stderr: %s", code, tt.code, errOut)
+			}
+			if code == exitOK && out != tt.want {
+				t.Errorf("rendered %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
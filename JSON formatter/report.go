@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// excerptWidth caps how much of a source line is shown around the caret,
+// minified documents being one very long line
+const excerptWidth = 80
+
+// lineIndex maps byte offsets of a document to line and column numbers
+type lineIndex struct {
+	data   []byte
+	starts []int
+}
+
+func newLineIndex(data []byte) *lineIndex {
+	starts := []int{0}
+	for i, c := range data {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return &lineIndex{data: data, starts: starts}
+}
+
+// position returns the 1-based line and column (in characters) of offset
+func (li *lineIndex) position(offset int) (line, col int) {
+	if offset > len(li.data) {
+		offset = len(li.data)
+	}
+	line = sort.Search(len(li.starts), func(i int) bool { return li.starts[i] > offset })
+	start := li.starts[line-1]
+	return line, utf8.RuneCount(li.data[start:offset]) + 1
+}
+
+// lineText returns the given 1-based line without its line ending
+func (li *lineIndex) lineText(line int) []byte {
+	start := li.starts[line-1]
+	end := len(li.data)
+	if line < len(li.starts) {
+		end = li.starts[line] - 1
+	}
+	return bytes.TrimRight(li.data[start:end], "\r")
+}
+
+// writeSyntaxReport prints each issue as name:line:col with the offending
+// source line and a caret under the column
+func writeSyntaxReport(w io.Writer, name string, data []byte, issues []syntaxIssue) {
+	writeSyntaxReportAt(w, name, data, 1, issues)
+}
+
+// writeSyntaxReportAt is writeSyntaxReport for data that starts on line
+// first of the file, such as a single NDJSON record
+func writeSyntaxReportAt(w io.Writer, name string, data []byte, first int, issues []syntaxIssue) {
+	li := newLineIndex(data)
+	for _, is := range issues {
+		line, col := li.position(is.offset)
+		shown := line + first - 1
+		if is.cause != "" {
+			fmt.Fprintf(w, "%s:%d:%d: %s (likely cause: %s)\n", name, shown, col, is.msg, is.cause)
+		} else {
+			fmt.Fprintf(w, "%s:%d:%d: %s\n", name, shown, col, is.msg)
+		}
+
+		text, caret := excerpt(li.lineText(line), col)
+		gutter := fmt.Sprintf("%5d | ", shown)
+		fmt.Fprintf(w, "%s%s\n", gutter, text)
+		fmt.Fprintf(w, "%s| %s^\n", strings.Repeat(" ", len(gutter)-2), caret)
+	}
+	if len(issues) > 1 {
+		fmt.Fprintf(w, "%s: %d problems\n", name, len(issues))
+	}
+}
+
+// excerpt cuts a window of the line around col and returns it with the
+// padding that puts a caret under col. Tabs are kept in the padding so
+// the caret lines up however the terminal expands them.
+func excerpt(line []byte, col int) (text, pad string) {
+	runes := []rune(string(line))
+	at := col - 1
+	start, end := 0, len(runes)
+	prefix, suffix := "", ""
+	if len(runes) > excerptWidth {
+		start = at - excerptWidth/2
+		if start < 0 {
+			start = 0
+		}
+		end = start + excerptWidth
+		if end > len(runes) {
+			end = len(runes)
+			start = end - excerptWidth
+		}
+		if start > 0 {
+			prefix = "..."
+		}
+		if end < len(runes) {
+			suffix = "..."
+		}
+	}
+	if at > end {
+		at = end
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat(" ", len(prefix)))
+	for _, r := range runes[start:at] {
+		if r == '\t' {
+			sb.WriteRune('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+	return prefix + string(runes[start:end]) + suffix, sb.String()
+}
@@ -0,0 +1,299 @@
+// Package schema validates documents against a JSON Schema (draft
+// 2020-12).
+//
+// The supported vocabulary covers what config files need: type, enum,
+// const, the numeric, string, array and object bounds, pattern,
+// properties, patternProperties, additionalProperties, required, items,
+// prefixItems, uniqueItems, allOf, anyOf, oneOf, not, $defs and local
+// $ref. Unknown keywords are ignored as the specification asks.
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// Schema is a compiled schema
+type Schema struct {
+	// always is set for the boolean schemas true and false
+	always *bool
+
+	types      []string
+	enum       []interface{}
+	constant   interface{}
+	hasConst   bool
+	ref        string
+	refSchema  *Schema
+	pattern    *regexp.Regexp
+	multipleOf *float64
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+
+	minLength, maxLength         *int
+	minItems, maxItems           *int
+	minProperties, maxProperties *int
+	uniqueItems                  bool
+
+	properties           []property
+	patternProperties    []patternProperty
+	additionalProperties *Schema
+	required             []string
+
+	prefixItems []*Schema
+	items       *Schema
+
+	allOf, anyOf, oneOf []*Schema
+	not                 *Schema
+}
+
+type property struct {
+	name   string
+	schema *Schema
+}
+
+type patternProperty struct {
+	re     *regexp.Regexp
+	schema *Schema
+}
+
+// compiler turns schema documents into Schemas, sharing the result for
+// every $ref to the same location so recursive schemas terminate
+type compiler struct {
+	root  interface{}
+	byPtr map[string]*Schema
+}
+
+// Compile checks and compiles a schema document as decoded by package
+// tree
+func Compile(doc interface{}) (*Schema, error) {
+	c := &compiler{root: doc, byPtr: make(map[string]*Schema)}
+	return c.compile(doc, "")
+}
+
+// SchemaError is a problem with the schema itself
+type SchemaError struct {
+	Pointer string
+	Msg     string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("schema: %s at #%s", e.Msg, e.Pointer)
+}
+
+func (c *compiler) compile(v interface{}, ptr string) (*Schema, error) {
+	if b, ok := v.(bool); ok {
+		return &Schema{always: &b}, nil
+	}
+	obj, ok := v.(tree.Object)
+	if !ok {
+		return nil, &SchemaError{Pointer: ptr, Msg: "a schema must be an object or a boolean"}
+	}
+	if s, ok := c.byPtr[ptr]; ok {
+		return s, nil
+	}
+	s := &Schema{}
+	c.byPtr[ptr] = s
+
+	for _, m := range obj {
+		at := ptr + tree.Pointer(m.Key)
+		var err error
+		switch m.Key {
+		case "type":
+			if s.types, err = stringList(m.Value, at, true); err == nil {
+				err = checkTypes(s.types, at)
+			}
+		case "enum":
+			arr, ok := m.Value.([]interface{})
+			if !ok {
+				err = &SchemaError{Pointer: at, Msg: "enum must be an array"}
+			}
+			s.enum = arr
+		case "const":
+			s.constant, s.hasConst = m.Value, true
+		case "$ref":
+			err = c.compileRef(s, m.Value, at)
+		case "pattern":
+			s.pattern, err = compilePattern(m.Value, at)
+		case "multipleOf":
+			s.multipleOf, err = numberKeyword(m.Value, at)
+		case "minimum":
+			s.minimum, err = numberKeyword(m.Value, at)
+		case "maximum":
+			s.maximum, err = numberKeyword(m.Value, at)
+		case "exclusiveMinimum":
+			s.exclusiveMinimum, err = numberKeyword(m.Value, at)
+		case "exclusiveMaximum":
+			s.exclusiveMaximum, err = numberKeyword(m.Value, at)
+		case "minLength":
+			s.minLength, err = countKeyword(m.Value, at)
+		case "maxLength":
+			s.maxLength, err = countKeyword(m.Value, at)
+		case "minItems":
+			s.minItems, err = countKeyword(m.Value, at)
+		case "maxItems":
+			s.maxItems, err = countKeyword(m.Value, at)
+		case "minProperties":
+			s.minProperties, err = countKeyword(m.Value, at)
+		case "maxProperties":
+			s.maxProperties, err = countKeyword(m.Value, at)
+		case "uniqueItems":
+			s.uniqueItems, _ = m.Value.(bool)
+		case "required":
+			s.required, err = stringList(m.Value, at, false)
+		case "properties", "patternProperties", "$defs", "definitions":
+			err = c.compileMap(s, m.Key, m.Value, at)
+		case "additionalProperties":
+			s.additionalProperties, err = c.compile(m.Value, at)
+		case "items":
+			s.items, err = c.compile(m.Value, at)
+		case "prefixItems":
+			s.prefixItems, err = c.compileList(m.Value, at)
+		case "allOf":
+			s.allOf, err = c.compileList(m.Value, at)
+		case "anyOf":
+			s.anyOf, err = c.compileList(m.Value, at)
+		case "oneOf":
+			s.oneOf, err = c.compileList(m.Value, at)
+		case "not":
+			s.not, err = c.compile(m.Value, at)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (c *compiler) compileMap(s *Schema, keyword string, v interface{}, ptr string) error {
+	obj, ok := v.(tree.Object)
+	if !ok {
+		return &SchemaError{Pointer: ptr, Msg: keyword + " must be an object"}
+	}
+	for _, m := range obj {
+		sub, err := c.compile(m.Value, ptr+tree.Pointer(m.Key))
+		if err != nil {
+			return err
+		}
+		switch keyword {
+		case "properties":
+			s.properties = append(s.properties, property{name: m.Key, schema: sub})
+		case "patternProperties":
+			re, err := compilePattern(m.Key, ptr+tree.Pointer(m.Key))
+			if err != nil {
+				return err
+			}
+			s.patternProperties = append(s.patternProperties, patternProperty{re: re, schema: sub})
+		}
+		// $defs are compiled only to be found by $ref
+	}
+	return nil
+}
+
+func (c *compiler) compileList(v interface{}, ptr string) ([]*Schema, error) {
+	arr, ok := v.([]interface{})
+	if !ok || len(arr) == 0 {
+		return nil, &SchemaError{Pointer: ptr, Msg: "must be a non-empty array of schemas"}
+	}
+	list := make([]*Schema, len(arr))
+	for i, item := range arr {
+		sub, err := c.compile(item, ptr+tree.Pointer(strconv.Itoa(i)))
+		if err != nil {
+			return nil, err
+		}
+		list[i] = sub
+	}
+	return list, nil
+}
+
+// compileRef resolves a local reference such as "#/$defs/address"
+func (c *compiler) compileRef(s *Schema, v interface{}, ptr string) error {
+	ref, ok := v.(string)
+	if !ok {
+		return &SchemaError{Pointer: ptr, Msg: "$ref must be a string"}
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return &SchemaError{Pointer: ptr, Msg: fmt.Sprintf("only local $ref are supported, not %q", ref)}
+	}
+	target := ref[1:]
+	s.ref = ref
+	if existing, ok := c.byPtr[target]; ok {
+		s.refSchema = existing
+		return nil
+	}
+	doc, err := tree.Resolve(c.root, target)
+	if err != nil {
+		return &SchemaError{Pointer: ptr, Msg: fmt.Sprintf("$ref %q: %v", ref, err)}
+	}
+	s.refSchema, err = c.compile(doc, target)
+	return err
+}
+
+func compilePattern(v interface{}, ptr string) (*regexp.Regexp, error) {
+	p, ok := v.(string)
+	if !ok {
+		return nil, &SchemaError{Pointer: ptr, Msg: "pattern must be a string"}
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, &SchemaError{Pointer: ptr, Msg: fmt.Sprintf("bad pattern %q: %v", p, err)}
+	}
+	return re, nil
+}
+
+func stringList(v interface{}, ptr string, single bool) ([]string, error) {
+	if s, ok := v.(string); ok && single {
+		return []string{s}, nil
+	}
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, &SchemaError{Pointer: ptr, Msg: "must be an array of strings"}
+	}
+	list := make([]string, len(arr))
+	for i, item := range arr {
+		s, ok := item.(string)
+		if !ok {
+			return nil, &SchemaError{Pointer: ptr, Msg: "must be an array of strings"}
+		}
+		list[i] = s
+	}
+	return list, nil
+}
+
+// typeNames are the values the type keyword accepts
+var typeNames = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// checkTypes rejects misspelled type names, which would otherwise never
+// match and fail every value with a puzzling message
+func checkTypes(types []string, ptr string) error {
+	for _, t := range types {
+		if !typeNames[t] {
+			return &SchemaError{Pointer: ptr, Msg: fmt.Sprintf("unknown type %q", t)}
+		}
+	}
+	return nil
+}
+
+func numberKeyword(v interface{}, ptr string) (*float64, error) {
+	f, ok := tree.ToFloat(v)
+	if !ok {
+		return nil, &SchemaError{Pointer: ptr, Msg: "must be a number"}
+	}
+	return &f, nil
+}
+
+func countKeyword(v interface{}, ptr string) (*int, error) {
+	f, ok := tree.ToFloat(v)
+	if !ok || f < 0 || f != float64(int(f)) {
+		return nil, &SchemaError{Pointer: ptr, Msg: "must be a non-negative integer"}
+	}
+	n := int(f)
+	return &n, nil
+}
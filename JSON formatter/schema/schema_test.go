@@ -0,0 +1,104 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/tree"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	v, err := tree.Decode(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("decoding %s: %v", s, err)
+	}
+	return v
+}
+
+var validateTests = []struct {
+	name, schema, doc string
+	want              []string // pointers of the violations, in order
+}{
+	{"true", `true`, `{"a":1}`, nil},
+	{"false", `false`, `1`, []string{""}},
+	{"type", `{"type":"string"}`, `1`, []string{""}},
+	{"type list", `{"type":["string","null"]}`, `null`, nil},
+	{"integer", `{"type":"integer"}`, `1.0`, nil},
+	{"not an integer", `{"type":"integer"}`, `1.5`, []string{""}},
+	{"integer beyond float64", `{"type":"integer"}`, `1e400`, nil},
+	{"number beyond float64", `{"type":"number"}`, `-1e400`, nil},
+	{"enum by value", `{"enum":[1,"a",{"b":[2]}]}`, `{"b":[2.0]}`, nil},
+	{"enum miss", `{"enum":[1,"a"]}`, `"b"`, []string{""}},
+	{"const", `{"const":{"a":1,"b":2}}`, `{"b":2,"a":1}`, nil},
+	{"const miss", `{"const":1}`, `1.0000001`, []string{""}},
+	{"minimum", `{"minimum":1,"exclusiveMaximum":10}`, `10`, []string{""}},
+	{"multipleOf", `{"multipleOf":0.5}`, `2.5`, nil},
+	{"string bounds", `{"minLength":2,"maxLength":3,"pattern":"^a"}`, `"abcd"`, []string{""}},
+	{"length counts characters", `{"maxLength":2}`, `"äö"`, nil},
+	{"required", `{"required":["a","b"]}`, `{"a":1}`, []string{""}},
+	{"properties", `{"properties":{"a":{"type":"integer"},"b":{"type":"string"}}}`, `{"a":"x","b":1}`,
+		[]string{"/a", "/b"}},
+	{"additionalProperties", `{"properties":{"a":true},"additionalProperties":false}`, `{"a":1,"b":2}`,
+		[]string{"/b"}},
+	{"patternProperties", `{"patternProperties":{"^x-":{"type":"string"}}}`, `{"x-a":1,"y":1}`,
+		[]string{"/x-a"}},
+	{"items", `{"items":{"type":"integer"}}`, `[1,"a",3,"b"]`, []string{"/1", "/3"}},
+	{"prefixItems", `{"prefixItems":[{"type":"string"}],"items":{"type":"integer"}}`, `["a",1,"b"]`,
+		[]string{"/2"}},
+	{"uniqueItems by value", `{"uniqueItems":true}`, `[1,{"a":1},1.0]`, []string{""}},
+	{"array bounds", `{"minItems":2}`, `[1]`, []string{""}},
+	{"anyOf", `{"anyOf":[{"type":"string"},{"type":"integer"}]}`, `true`, []string{""}},
+	{"oneOf matching two", `{"oneOf":[{"type":"integer"},{"minimum":0}]}`, `1`, []string{""}},
+	{"oneOf matching one", `{"oneOf":[{"type":"integer"},{"minimum":0}]}`, `-1`, nil},
+	{"allOf", `{"allOf":[{"type":"integer"},{"minimum":5}]}`, `3`, []string{""}},
+	{"not", `{"not":{"type":"null"}}`, `null`, []string{""}},
+	{"ref", `{"$defs":{"port":{"type":"integer","maximum":65535}},"properties":{"port":{"$ref":"#/$defs/port"}}}`,
+		`{"port":70000}`, []string{"/port"}},
+	{"recursive ref", `{"properties":{"child":{"$ref":"#"}},"required":["name"]}`,
+		`{"name":"a","child":{"name":"b","child":{}}}`, []string{"/child/child"}},
+	{"nested pointer escaping", `{"properties":{"a/b":{"type":"string"}}}`, `{"a/b":1}`, []string{"/a~1b"}},
+}
+
+func TestValidate(t *testing.T) {
+	for _, tt := range validateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Compile(decode(t, tt.schema))
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			var got []string
+			for _, v := range s.Validate(decode(t, tt.doc)) {
+				got = append(got, v.Pointer)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") || len(got) != len(tt.want) {
+				t.Errorf("violations at %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypeMessage(t *testing.T) {
+	s, err := Compile(decode(t, `{"type":"string"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := s.Validate(decode(t, `1e400`))
+	if len(v) != 1 || !strings.Contains(v[0].Message, "got integer") {
+		t.Errorf("violations %v, want one naming an integer", v)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		`1`,
+		`{"type":"text"}`,
+		`{"minLength":-1}`,
+		`{"pattern":"("}`,
+		`{"$ref":"#/$defs/missing"}`,
+	} {
+		if _, err := Compile(decode(t, src)); err == nil {
+			t.Errorf("Compile(%s) succeeded, want an error", src)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// Violation is one way a document fails its schema
+type Violation struct {
+	// Pointer is the JSON Pointer of the offending value, "" for the root
+	Pointer string
+	Message string
+}
+
+func (v Violation) String() string {
+	ptr := v.Pointer
+	if ptr == "" {
+		ptr = "/"
+	}
+	return ptr + ": " + v.Message
+}
+
+// Validate returns every violation of s found in doc, none when it is
+// valid
+func (s *Schema) Validate(doc interface{}) []Violation {
+	var out []Violation
+	s.validate(doc, "", &out)
+	return out
+}
+
+// valid is Validate for the branches of anyOf, oneOf and not, which only
+// need a yes or no
+func (s *Schema) valid(v interface{}, ptr string) bool {
+	var out []Violation
+	s.validate(v, ptr, &out)
+	return len(out) == 0
+}
+
+func (s *Schema) validate(v interface{}, ptr string, out *[]Violation) {
+	report := func(format string, args ...interface{}) {
+		*out = append(*out, Violation{Pointer: ptr, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.always != nil {
+		if !*s.always {
+			report("no value is allowed here")
+		}
+		return
+	}
+	if s.refSchema != nil {
+		s.refSchema.validate(v, ptr, out)
+	}
+
+	if len(s.types) > 0 && !s.typeMatches(v) {
+		report("expected %s, got %s", strings.Join(s.types, " or "), typeName(v))
+		// the remaining keywords would only repeat the mismatch
+		return
+	}
+	if s.enum != nil && !contains(s.enum, v) {
+		report("value %s is not one of %s", display(v), display(s.enum))
+	}
+	if s.hasConst && !tree.Equal(s.constant, v) {
+		report("value must be %s", display(s.constant))
+	}
+
+	switch x := v.(type) {
+	case string:
+		s.validateString(x, report)
+	case []interface{}:
+		s.validateArray(x, ptr, out, report)
+	case tree.Object:
+		s.validateObject(x, ptr, out, report)
+	default:
+		if f, ok := tree.ToFloat(v); ok {
+			s.validateNumber(f, report)
+		}
+	}
+
+	for _, sub := range s.allOf {
+		sub.validate(v, ptr, out)
+	}
+	if s.anyOf != nil {
+		matched := false
+		for _, sub := range s.anyOf {
+			if sub.valid(v, ptr) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			report("value matches none of the anyOf schemas")
+		}
+	}
+	if s.oneOf != nil {
+		n := 0
+		for _, sub := range s.oneOf {
+			if sub.valid(v, ptr) {
+				n++
+			}
+		}
+		if n != 1 {
+			report("value must match exactly one oneOf schema, matched %d", n)
+		}
+	}
+	if s.not != nil && s.not.valid(v, ptr) {
+		report("value must not match the schema in not")
+	}
+}
+
+func (s *Schema) typeMatches(v interface{}) bool {
+	actual := typeName(v)
+	for _, t := range s.types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case tree.Object:
+		return "object"
+	}
+	if isInt, ok := tree.IsInteger(v); ok {
+		if isInt {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func (s *Schema) validateNumber(f float64, report func(string, ...interface{})) {
+	if s.minimum != nil && f < *s.minimum {
+		report("%s is less than the minimum %s", fmtFloat(f), fmtFloat(*s.minimum))
+	}
+	if s.maximum != nil && f > *s.maximum {
+		report("%s is greater than the maximum %s", fmtFloat(f), fmtFloat(*s.maximum))
+	}
+	if s.exclusiveMinimum != nil && f <= *s.exclusiveMinimum {
+		report("%s must be greater than %s", fmtFloat(f), fmtFloat(*s.exclusiveMinimum))
+	}
+	if s.exclusiveMaximum != nil && f >= *s.exclusiveMaximum {
+		report("%s must be less than %s", fmtFloat(f), fmtFloat(*s.exclusiveMaximum))
+	}
+	if s.multipleOf != nil && *s.multipleOf != 0 {
+		q := f / *s.multipleOf
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			report("%s is not a multiple of %s", fmtFloat(f), fmtFloat(*s.multipleOf))
+		}
+	}
+}
+
+func (s *Schema) validateString(str string, report func(string, ...interface{})) {
+	n := utf8.RuneCountInString(str)
+	if s.minLength != nil && n < *s.minLength {
+		report("string is shorter than %d characters", *s.minLength)
+	}
+	if s.maxLength != nil && n > *s.maxLength {
+		report("string is longer than %d characters", *s.maxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(str) {
+		report("%s does not match the pattern %q", display(str), s.pattern)
+	}
+}
+
+func (s *Schema) validateArray(arr []interface{}, ptr string, out *[]Violation, report func(string, ...interface{})) {
+	if s.minItems != nil && len(arr) < *s.minItems {
+		report("array has %d items, fewer than %d", len(arr), *s.minItems)
+	}
+	if s.maxItems != nil && len(arr) > *s.maxItems {
+		report("array has %d items, more than %d", len(arr), *s.maxItems)
+	}
+	if s.uniqueItems {
+		for i := range arr {
+			for j := 0; j < i; j++ {
+				if tree.Equal(arr[i], arr[j]) {
+					report("items %d and %d are equal but must be unique", j, i)
+				}
+			}
+		}
+	}
+	for i, item := range arr {
+		at := ptr + tree.Pointer(strconv.Itoa(i))
+		switch {
+		case i < len(s.prefixItems):
+			s.prefixItems[i].validate(item, at, out)
+		case s.items != nil:
+			s.items.validate(item, at, out)
+		}
+	}
+}
+
+func (s *Schema) validateObject(obj tree.Object, ptr string, out *[]Violation, report func(string, ...interface{})) {
+	if s.minProperties != nil && len(obj) < *s.minProperties {
+		report("object has %d properties, fewer than %d", len(obj), *s.minProperties)
+	}
+	if s.maxProperties != nil && len(obj) > *s.maxProperties {
+		report("object has %d properties, more than %d", len(obj), *s.maxProperties)
+	}
+	for _, name := range s.required {
+		if _, ok := obj.Get(name); !ok {
+			report("missing required property %q", name)
+		}
+	}
+
+	for _, m := range obj {
+		at := ptr + tree.Pointer(m.Key)
+		matched := false
+		for _, p := range s.properties {
+			if p.name == m.Key {
+				p.schema.validate(m.Value, at, out)
+				matched = true
+			}
+		}
+		for _, pp := range s.patternProperties {
+			if pp.re.MatchString(m.Key) {
+				pp.schema.validate(m.Value, at, out)
+				matched = true
+			}
+		}
+		if matched || s.additionalProperties == nil {
+			continue
+		}
+		if a := s.additionalProperties.always; a != nil && !*a {
+			*out = append(*out, Violation{Pointer: at, Message: fmt.Sprintf("additional property %q is not allowed", m.Key)})
+			continue
+		}
+		s.additionalProperties.validate(m.Value, at, out)
+	}
+}
+
+func contains(list []interface{}, v interface{}) bool {
+	for _, item := range list {
+		if tree.Equal(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// display formats a value for messages in JSON-like notation
+func display(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(x)
+	case []interface{}:
+		parts := make([]string, len(x))
+		for i, item := range x {
+			parts[i] = display(item)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case tree.Object:
+		keys := x.Keys()
+		sort.Strings(keys)
+		return "{" + strings.Join(keys, ", ") + "}"
+	}
+	if f, ok := tree.ToFloat(v); ok {
+		return fmtFloat(f)
+	}
+	return fmt.Sprint(v)
+}
+
+func fmtFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
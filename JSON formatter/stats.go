@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"example.com/json-view-formatter/encode"
+	"example.com/json-view-formatter/query"
+	"example.com/json-view-formatter/tree"
+)
+
+// statsTop is how many of the largest arrays and longest strings are
+// listed
+const statsTop = 5
+
+// typeNames lists the value types in the order the report counts them
+var typeNames = []string{"object", "array", "string", "number", "boolean", "null"}
+
+// docStats is the shape of a document: how deep it goes, what it is
+// made of and where the bulk of it is
+type docStats struct {
+	size     int
+	maxDepth int
+	types    map[string]int
+	arrays   []pathSize
+	strings  []pathSize
+	paths    []pathSize // key paths with array indexes as [*], counted
+	pathIdx  map[string]int
+	subtrees []pathSize // compact JSON size of each top-level member
+}
+
+type pathSize struct {
+	path string
+	n    int
+}
+
+// collectStats walks doc the way the tree view prints it
+func collectStats(doc interface{}) *docStats {
+	s := &docStats{types: map[string]int{}, pathIdx: map[string]int{}}
+	s.size = jsonSize(doc)
+	s.visit(doc, "$", "$", 0)
+	if isNested(doc) {
+		eachMember(doc, func(key string, index int, value interface{}) {
+			s.subtrees = append(s.subtrees, pathSize{memberPath("$", key, index), jsonSize(value)})
+		})
+	}
+	s.arrays = top(s.arrays)
+	s.strings = top(s.strings)
+	return s
+}
+
+// visit records v found at path; shape is the path with every array
+// index replaced by [*]
+func (s *docStats) visit(v interface{}, path, shape string, depth int) {
+	s.maxDepth = max(s.maxDepth, depth)
+	s.types[valueType(v)]++
+	switch x := v.(type) {
+	case string:
+		s.strings = append(s.strings, pathSize{path, utf8.RuneCountInString(x)})
+	case []interface{}:
+		s.arrays = append(s.arrays, pathSize{path, len(x)})
+	}
+	if !isNested(v) {
+		return
+	}
+	eachMember(v, func(key string, index int, value interface{}) {
+		childShape := shape + "[*]"
+		if index < 0 {
+			childShape = query.Join(shape, key)
+			if i, ok := s.pathIdx[childShape]; ok {
+				s.paths[i].n++
+			} else {
+				s.pathIdx[childShape] = len(s.paths)
+				s.paths = append(s.paths, pathSize{childShape, 1})
+			}
+		}
+		s.visit(value, memberPath(path, key, index), childShape, depth+1)
+	})
+}
+
+func memberPath(path, key string, index int) string {
+	if index >= 0 {
+		return path + "[" + strconv.Itoa(index) + "]"
+	}
+	return query.Join(path, key)
+}
+
+// top keeps the statsTop largest entries, the first found winning ties
+func top(list []pathSize) []pathSize {
+	sort.SliceStable(list, func(i, j int) bool { return list[i].n > list[j].n })
+	if len(list) > statsTop {
+		list = list[:statsTop]
+	}
+	return list
+}
+
+func valueType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	if isNested(v) {
+		return "object"
+	}
+	return "number"
+}
+
+// jsonSize is the length of v as compact JSON
+func jsonSize(v interface{}) int {
+	var c countWriter
+	enc, _ := encode.New("compact", encode.DefaultOptions)
+	enc.Encode(&c, v)
+	return int(c) - 1 // the encoder's final newline
+}
+
+type countWriter int
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	*c += countWriter(len(p))
+	return len(p), nil
+}
+
+// tree returns the statistics as a document for the output encoders
+func (s *docStats) tree() tree.Object {
+	types := tree.Object{}
+	for _, t := range typeNames {
+		types = append(types, tree.Member{Key: t, Value: json.Number(strconv.Itoa(s.types[t]))})
+	}
+	list := func(entries []pathSize, field string) []interface{} {
+		out := []interface{}{}
+		for _, e := range entries {
+			out = append(out, tree.Object{{Key: "path", Value: e.path}, {Key: field, Value: json.Number(strconv.Itoa(e.n))}})
+		}
+		return out
+	}
+	return tree.Object{
+		{Key: "bytes", Value: json.Number(strconv.Itoa(s.size))},
+		{Key: "maxDepth", Value: json.Number(strconv.Itoa(s.maxDepth))},
+		{Key: "types", Value: types},
+		{Key: "largestArrays", Value: list(s.arrays, "length")},
+		{Key: "longestStrings", Value: list(s.strings, "length")},
+		{Key: "keyPaths", Value: list(s.paths, "count")},
+		{Key: "subtrees", Value: list(s.subtrees, "bytes")},
+	}
+}
+
+// writeStats prints the statistics as a text report
+func writeStats(w io.Writer, s *docStats) error {
+	ew := &errWriter{w: w}
+	ew.printf("size: %d bytes as compact JSON, max depth %d\n", s.size, s.maxDepth)
+	ew.printf("types:")
+	for _, t := range typeNames {
+		ew.printf(" %s %d", t, s.types[t])
+	}
+	ew.printf("\n")
+
+	tw := tabwriter.NewWriter(ew.w, 0, 0, 2, ' ', 0)
+	section := func(title, unit string, entries []pathSize) {
+		if len(entries) == 0 || ew.err != nil {
+			return
+		}
+		ew.printf("%s:\n", title)
+		for _, e := range entries {
+			fmt.Fprintf(tw, "  %s\t%d%s\n", e.path, e.n, unit)
+		}
+		ew.err = tw.Flush()
+	}
+	section("largest arrays", " items", s.arrays)
+	section("longest strings", " chars", s.strings)
+	section("key paths (occurrences)", "", s.paths)
+	section("top-level subtrees", " bytes", s.subtrees)
+	return ew.err
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// writeError marks a failure of the output rather than of the input
+type writeError struct {
+	err error
+}
+
+func (e *writeError) Error() string { return e.err.Error() }
+func (e *writeError) Unwrap() error { return e.err }
+
+// streamFrame is one open object or array on the streaming printer's stack
+type streamFrame struct {
+	array  bool
+	index  int
+	indent string
+}
+
+// streamPrint renders the JSON document read from r in the same layout
+// prettyPrint gives a decoded tree, but token by token: memory use is
+// bounded by the nesting depth, not the document size. Output stops at
+// the last complete value before a syntax error, so nothing after the
+// error position is printed.
+func streamPrint(w io.Writer, r io.Reader, indent string, th *theme) error {
+	dec := tree.NewDecoder(r)
+	p := &printer{errWriter: errWriter{w: w}, theme: th}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	var stack []streamFrame
+	if d, ok := tok.(json.Delim); ok && dec.More() {
+		stack = append(stack, streamFrame{array: d == '[', indent: indent})
+	} else if tok, err = streamLeaf(dec, tok); err != nil {
+		return err
+	} else {
+		p.printf("%s%s\n", indent, p.scalar(tok))
+	}
+
+	for len(stack) > 0 && p.err == nil {
+		top := &stack[len(stack)-1]
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		var label string
+		if top.array {
+			label = p.indexLabel(top.index)
+			top.index++
+		} else {
+			label = p.keyLabel(tok.(string))
+			if tok, err = dec.Token(); err != nil {
+				return err
+			}
+		}
+
+		if d, ok := tok.(json.Delim); ok && dec.More() {
+			p.printf("%s%s%s\n", top.indent, label, p.theme.paint(stylePunct, ":"))
+			stack = append(stack, streamFrame{array: d == '[', indent: top.indent + "  "})
+		} else {
+			if tok, err = streamLeaf(dec, tok); err != nil {
+				return err
+			}
+			p.printf("%s%s%s %s\n", top.indent, label, p.theme.paint(stylePunct, ":"), p.scalar(tok))
+		}
+	}
+	if p.err != nil {
+		return &writeError{p.err}
+	}
+
+	return dec.End()
+}
+
+// streamLeaf returns the value printed on one line for tok: tok itself,
+// or for the opening delimiter of an empty object or array the empty
+// container, its closing delimiter read
+func streamLeaf(dec *tree.Decoder, tok json.Token) (interface{}, error) {
+	d, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	if d == '[' {
+		return []interface{}{}, nil
+	}
+	return tree.Object{}, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/tree"
+)
+
+// the streaming printer shows what prettyPrint shows for the decoded
+// document and fails where decoding fails
+func TestStreamMatchesTree(t *testing.T) {
+	docs := []string{
+		`{"a":[1,{},[]],"b":{"c":null,"d":"x"},"e":1.50}`,
+		`[[], [[1]], {"k": {}}]`,
+		`"scalar"`,
+		`{}`,
+		` [] `,
+		`{"a":[1,2`,
+		`{"a":1}}`,
+		`{"a":1} x`,
+		`[1,]`,
+		``,
+		`   `,
+	}
+	for _, doc := range docs {
+		var streamed strings.Builder
+		streamErr := streamPrint(&streamed, strings.NewReader(doc), "  ", nil)
+		v, decodeErr := tree.Decode(strings.NewReader(doc))
+		if (streamErr == nil) != (decodeErr == nil) {
+			t.Errorf("%q: streaming gave %v, decoding %v", doc, streamErr, decodeErr)
+			continue
+		}
+		if decodeErr != nil {
+			continue
+		}
+		var printed strings.Builder
+		if err := prettyPrint(&printed, v, "  ", nil); err != nil {
+			t.Fatal(err)
+		}
+		if streamed.String() != printed.String() {
+			t.Errorf("%q streamed as\n%s\nprinted as\n%s", doc, streamed.String(), printed.String())
+		}
+	}
+}
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// maxSyntaxDepth matches the nesting limit of encoding/json
+const maxSyntaxDepth = 10000
+
+// likely causes named in syntax reports
+const (
+	causeTrailingComma  = "trailing comma"
+	causeUnquotedKey    = "unquoted key"
+	causeSingleQuotes   = "single quotes"
+	causeUnterminated   = "unterminated string"
+	causeMissingComma   = "missing comma"
+	causeMissingColon   = "missing colon"
+	causeMissingValue   = "missing value"
+	causeComment        = "comment"
+	causeBadLiteral     = "invalid literal"
+	causeBadNumber      = "invalid number"
+	causeBadEscape      = "invalid escape"
+	causeTruncated      = "unexpected end of input"
+	causeTrailingData   = "data after the document"
+	causeControlChar    = "control character in string"
+	causeUnexpectedChar = "unexpected character"
+)
+
+// syntaxIssue is one problem found in a document
+type syntaxIssue struct {
+	offset int
+	msg    string
+	cause  string
+}
+
+// abortScan unwinds the checker once it may not go on
+type abortScan struct{}
+
+// syntaxChecker is a recursive descent JSON recognizer that explains what
+// it finds. With all set it records a problem, guesses what the author
+// meant and keeps going; otherwise it stops at the first problem.
+type syntaxChecker struct {
+	data   []byte
+	pos    int
+	depth  int
+	all    bool
+	issues []syntaxIssue
+}
+
+// checkSyntax returns the syntax problems of data, only the first one
+// unless all is set
+func checkSyntax(data []byte, all bool) (issues []syntaxIssue) {
+	s := &syntaxChecker{data: data, all: all}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(abortScan); !ok {
+				panic(r)
+			}
+		}
+		issues = s.issues
+	}()
+
+	s.skipSpace()
+	if s.eof() {
+		s.fail(s.pos, "empty document", causeTruncated)
+		s.abort()
+	}
+	start := s.pos
+	s.value()
+	if s.pos == start {
+		// a stray closing bracket was already reported
+		return s.issues
+	}
+	s.skipSpace()
+	if !s.eof() {
+		s.fail(s.pos, fmt.Sprintf("unexpected %s after top-level value", s.describe()), causeTrailingData)
+	}
+	return s.issues
+}
+
+func (s *syntaxChecker) fail(offset int, msg, cause string) {
+	// one problem per position: a stray character seen by a value and
+	// then by its container is reported once
+	if n := len(s.issues); n == 0 || s.issues[n-1].offset != offset {
+		s.issues = append(s.issues, syntaxIssue{offset: offset, msg: msg, cause: cause})
+	}
+	if !s.all {
+		s.abort()
+	}
+}
+
+func (s *syntaxChecker) abort() { panic(abortScan{}) }
+
+func (s *syntaxChecker) eof() bool { return s.pos >= len(s.data) }
+
+func (s *syntaxChecker) peek() byte {
+	if s.eof() {
+		return 0
+	}
+	return s.data[s.pos]
+}
+
+// describe names the character at the current position for messages
+func (s *syntaxChecker) describe() string {
+	if s.eof() {
+		return "end of input"
+	}
+	r, _ := utf8.DecodeRune(s.data[s.pos:])
+	return fmt.Sprintf("%q", r)
+}
+
+// skipSpace skips whitespace and reports comments, which JSON lacks
+func (s *syntaxChecker) skipSpace() {
+	for !s.eof() {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		case '/':
+			start := s.pos
+			if !s.skipComment() {
+				return
+			}
+			s.fail(start, "comments are not allowed in JSON", causeComment)
+		default:
+			return
+		}
+	}
+}
+
+// skipComment moves past a // or /* */ comment at pos, if there is one
+func (s *syntaxChecker) skipComment() bool {
+	rest := s.data[s.pos:]
+	if len(rest) < 2 {
+		return false
+	}
+	switch rest[1] {
+	case '/':
+		for !s.eof() && s.data[s.pos] != '\n' {
+			s.pos++
+		}
+		return true
+	case '*':
+		s.pos += 2
+		for s.pos+1 < len(s.data) && !(s.data[s.pos] == '*' && s.data[s.pos+1] == '/') {
+			s.pos++
+		}
+		s.pos += 2
+		if s.pos > len(s.data) {
+			s.pos = len(s.data)
+		}
+		return true
+	}
+	return false
+}
+
+func (s *syntaxChecker) value() {
+	s.skipSpace()
+	if s.eof() {
+		s.fail(s.pos, "unexpected end of input, expected a value", causeTruncated)
+		s.abort()
+	}
+	switch c := s.peek(); {
+	case c == '{':
+		s.nested(s.object)
+	case c == '[':
+		s.nested(s.array)
+	case c == '"':
+		s.str()
+	case c == '\'':
+		s.singleQuoted("strings")
+	case c == '-' || (c >= '0' && c <= '9'):
+		s.number()
+	case isWordByte(c):
+		s.literal()
+	case c == '}' || c == ']' || c == ',':
+		// left for the enclosing container to resync on
+		s.fail(s.pos, fmt.Sprintf("unexpected %s, expected a value", s.describe()), s.guessCause())
+	default:
+		s.fail(s.pos, fmt.Sprintf("unexpected %s, expected a value", s.describe()), causeUnexpectedChar)
+		s.pos++
+	}
+}
+
+func (s *syntaxChecker) nested(parse func()) {
+	s.depth++
+	if s.depth > maxSyntaxDepth {
+		s.fail(s.pos, "exceeded max depth", causeUnexpectedChar)
+		s.abort()
+	}
+	parse()
+	s.depth--
+}
+
+// guessCause names the likely reason for a closing bracket or comma where
+// a value should start
+func (s *syntaxChecker) guessCause() string {
+	i := s.pos - 1
+	for i >= 0 && (s.data[i] == ' ' || s.data[i] == '\t' || s.data[i] == '\n' || s.data[i] == '\r') {
+		i--
+	}
+	if i >= 0 && s.data[i] == ',' && s.peek() != ',' {
+		return causeTrailingComma
+	}
+	return causeMissingValue
+}
+
+func (s *syntaxChecker) object() {
+	s.pos++ // '{'
+	for {
+		s.skipSpace()
+		start := s.pos
+		switch c := s.peek(); {
+		case s.eof():
+			s.fail(s.pos, "unexpected end of input, object is not closed", causeTruncated)
+			s.abort()
+		case c == '}':
+			s.pos++
+			return
+		case c == '"':
+			s.str()
+		case c == '\'':
+			s.singleQuoted("keys")
+		case isWordByte(c):
+			start := s.pos
+			word := s.word()
+			s.fail(start, fmt.Sprintf("object key %s must be a double-quoted string", word), causeUnquotedKey)
+		default:
+			s.fail(s.pos, fmt.Sprintf("unexpected %s, expected an object key", s.describe()), causeUnexpectedChar)
+			s.skipTo(",}")
+			if s.peek() == ',' {
+				s.pos++
+			}
+			continue
+		}
+
+		s.skipSpace()
+		if s.peek() == ':' {
+			s.pos++
+		} else {
+			s.fail(s.pos, fmt.Sprintf("unexpected %s, expected ':' after object key", s.describe()), causeMissingColon)
+		}
+		s.value()
+
+		if s.endOfMember('}') {
+			return
+		}
+		s.progress(start)
+	}
+}
+
+func (s *syntaxChecker) array() {
+	s.pos++ // '['
+	s.skipSpace()
+	if s.peek() == ']' {
+		s.pos++
+		return
+	}
+	for {
+		start := s.pos
+		s.value()
+		if s.endOfMember(']') {
+			return
+		}
+		s.progress(start)
+	}
+}
+
+// progress skips a byte when a container loop went round without moving,
+// so recovery cannot spin on input it does not understand
+func (s *syntaxChecker) progress(start int) {
+	if s.pos == start {
+		s.pos++
+	}
+}
+
+// endOfMember consumes what follows an object member or array element and
+// reports whether the container was closed
+func (s *syntaxChecker) endOfMember(closing byte) bool {
+	s.skipSpace()
+	switch c := s.peek(); {
+	case c == closing:
+		s.pos++
+		return true
+	case c == ',':
+		comma := s.pos
+		s.pos++
+		s.skipSpace()
+		if s.peek() == closing {
+			s.fail(comma, fmt.Sprintf("trailing comma before %q", closing), causeTrailingComma)
+			s.pos++
+			return true
+		}
+		return false
+	case s.eof():
+		s.fail(s.pos, fmt.Sprintf("unexpected end of input, expected ',' or %q", closing), causeTruncated)
+		s.abort()
+	case c == '}' || c == ']':
+		// the wrong closer: most likely a typo for the right one, so it
+		// closes this container rather than one further out
+		s.fail(s.pos, fmt.Sprintf("unexpected %s, expected ',' or %q", s.describe(), closing), causeUnexpectedChar)
+		s.pos++
+		return true
+	}
+	s.fail(s.pos, fmt.Sprintf("unexpected %s, expected ',' or %q", s.describe(), closing), causeMissingComma)
+	return false
+}
+
+// skipTo moves to the next byte of set, or the end of input
+func (s *syntaxChecker) skipTo(set string) {
+	for !s.eof() {
+		for i := 0; i < len(set); i++ {
+			if s.data[s.pos] == set[i] {
+				return
+			}
+		}
+		s.pos++
+	}
+}
+
+func (s *syntaxChecker) str() {
+	start := s.pos
+	s.pos++ // '"'
+	for !s.eof() {
+		c := s.data[s.pos]
+		switch {
+		case c == '"':
+			s.pos++
+			return
+		case c == '\n':
+			s.fail(start, "string is not terminated before the end of the line", causeUnterminated)
+			return
+		case c == '\\':
+			s.escape()
+			continue
+		case c < 0x20:
+			s.fail(s.pos, fmt.Sprintf("control character %q in string must be escaped", c), causeControlChar)
+		}
+		s.pos++
+	}
+	s.fail(start, "string is not terminated before the end of input", causeUnterminated)
+	s.abort()
+}
+
+func (s *syntaxChecker) escape() {
+	start := s.pos
+	s.pos++ // '\\'
+	if s.eof() {
+		return
+	}
+	c := s.data[s.pos]
+	s.pos++
+	switch c {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		return
+	case 'u':
+		for i := 0; i < 4; i++ {
+			if s.eof() || !isHexByte(s.data[s.pos]) {
+				s.fail(start, `\u must be followed by four hex digits`, causeBadEscape)
+				return
+			}
+			s.pos++
+		}
+		return
+	}
+	s.fail(start, fmt.Sprintf("invalid escape %q in string", "\\"+string(c)), causeBadEscape)
+}
+
+// singleQuoted skips a 'string' after reporting it
+func (s *syntaxChecker) singleQuoted(what string) {
+	start := s.pos
+	s.fail(start, fmt.Sprintf("%s must use double quotes, not single quotes", what), causeSingleQuotes)
+	s.pos++
+	for !s.eof() && s.data[s.pos] != '\'' && s.data[s.pos] != '\n' {
+		if s.data[s.pos] == '\\' {
+			s.pos++
+		}
+		s.pos++
+	}
+	if s.peek() == '\'' {
+		s.pos++
+	}
+}
+
+func (s *syntaxChecker) number() {
+	start := s.pos
+	bad := func() {
+		for isWordByte(s.peek()) || s.peek() == '.' || s.peek() == '+' || s.peek() == '-' {
+			s.pos++
+		}
+		s.fail(start, fmt.Sprintf("invalid number %q", s.data[start:s.pos]), causeBadNumber)
+	}
+	s.pos += len(s.peekOneOf("-"))
+	switch {
+	case s.peek() == '0':
+		s.pos++
+		if isDigitByte(s.peek()) {
+			bad()
+			return
+		}
+	case isDigitByte(s.peek()):
+		s.digits()
+	default:
+		bad()
+		return
+	}
+	if s.peek() == '.' {
+		s.pos++
+		if !isDigitByte(s.peek()) {
+			bad()
+			return
+		}
+		s.digits()
+	}
+	if s.peek() == 'e' || s.peek() == 'E' {
+		s.pos++
+		s.pos += len(s.peekOneOf("+-"))
+		if !isDigitByte(s.peek()) {
+			bad()
+			return
+		}
+		s.digits()
+	}
+	if isWordByte(s.peek()) {
+		bad()
+	}
+}
+
+// peekOneOf returns the next byte if it is one of set, without moving
+func (s *syntaxChecker) peekOneOf(set string) string {
+	if !s.eof() {
+		for i := 0; i < len(set); i++ {
+			if s.data[s.pos] == set[i] {
+				return set[i : i+1]
+			}
+		}
+	}
+	return ""
+}
+
+func (s *syntaxChecker) digits() {
+	for isDigitByte(s.peek()) {
+		s.pos++
+	}
+}
+
+func (s *syntaxChecker) literal() {
+	start := s.pos
+	switch word := s.word(); word {
+	case "true", "false", "null":
+	default:
+		s.fail(start, fmt.Sprintf("invalid literal %q, strings must be double-quoted", word), causeBadLiteral)
+	}
+}
+
+func (s *syntaxChecker) word() string {
+	start := s.pos
+	for !s.eof() && isWordByte(s.data[s.pos]) {
+		s.pos++
+	}
+	return string(s.data[start:s.pos])
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isDigitByte(c)
+}
+
+func isDigitByte(c byte) bool { return c >= '0' && c <= '9' }
+
+func isHexByte(c byte) bool {
+	return isDigitByte(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,133 @@
+package tree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Decode reads exactly one JSON document from r with json.Decoder.Token
+// so object members keep their source order. A repeated key keeps the
+// position of its first occurrence and the value of its last one, the
+// same value encoding/json would pick.
+func Decode(r io.Reader) (interface{}, error) {
+	dec := NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	v, err := decodeValue(dec, tok)
+	if err != nil {
+		return nil, err
+	}
+	if err := dec.End(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Decoder reads one JSON document token by token, numbers as
+// json.Number. Decode builds the tree with it and the streaming printer
+// renders from it, so both agree on what a document is.
+type Decoder struct {
+	dec *json.Decoder
+}
+
+// NewDecoder returns a decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &Decoder{dec: dec}
+}
+
+// Token is json.Decoder.Token with EOF before the document is complete
+// reported as truncation
+func (d *Decoder) Token() (json.Token, error) {
+	tok, err := d.dec.Token()
+	if errors.Is(err, io.EOF) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return tok, err
+}
+
+// More reports whether the current object or array has another member
+func (d *Decoder) More() bool {
+	return d.dec.More()
+}
+
+// End checks that nothing but whitespace follows the document
+func (d *Decoder) End() error {
+	if _, err := d.dec.Token(); err != io.EOF {
+		if err == nil {
+			err = fmt.Errorf("invalid character after top-level value at offset %d", d.dec.InputOffset())
+		}
+		return err
+	}
+	return nil
+}
+
+func decodeValue(dec *Decoder, tok json.Token) (interface{}, error) {
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			return decodeObject(dec)
+		case '[':
+			return decodeArray(dec)
+		}
+		return nil, fmt.Errorf("unexpected %q at offset %d", rune(t), dec.dec.InputOffset())
+	default:
+		return t, nil
+	}
+}
+
+func decodeObject(dec *Decoder) (interface{}, error) {
+	obj := Object{}
+	index := make(map[string]int)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if tok == json.Delim('}') {
+			return obj, nil
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected object key at offset %d", dec.dec.InputOffset())
+		}
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		val, err := decodeValue(dec, tok)
+		if err != nil {
+			return nil, err
+		}
+		if i, dup := index[key]; dup {
+			obj[i].Value = val
+			continue
+		}
+		index[key] = len(obj)
+		obj = append(obj, Member{Key: key, Value: val})
+	}
+}
+
+func decodeArray(dec *Decoder) (interface{}, error) {
+	arr := []interface{}{}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if tok == json.Delim(']') {
+			return arr, nil
+		}
+		val, err := decodeValue(dec, tok)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, val)
+	}
+}
@@ -0,0 +1,283 @@
+package tree
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// decimal is a number as sign, significant digits and exponent, its value
+// being 0.digits × 10^exp. Zero has no digits.
+type decimal struct {
+	neg    bool
+	digits string
+	exp    int
+}
+
+// maxExponent bounds exponents so that absurd ones cannot overflow the
+// arithmetic; no float64 comes near it
+const maxExponent = 1 << 30
+
+// parseDecimal reads a decimal number. Besides the JSON grammar it takes
+// a leading '+', leading zeros and a '.' with no digits on one side, the
+// spellings JSON5, YAML and TOML allow.
+func parseDecimal(s string) (decimal, bool) {
+	var d decimal
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		d.neg = s[0] == '-'
+		s = s[1:]
+	}
+	mant, exp, hasExp := strings.Cut(strings.ToLower(s), "e")
+	intPart, frac, _ := strings.Cut(mant, ".")
+	if intPart == "" && frac == "" || strings.Trim(intPart+frac, "0123456789") != "" {
+		return d, false
+	}
+	e := 0
+	if hasExp {
+		esign := 1
+		if exp != "" && (exp[0] == '-' || exp[0] == '+') {
+			if exp[0] == '-' {
+				esign = -1
+			}
+			exp = exp[1:]
+		}
+		if exp == "" || strings.Trim(exp, "0123456789") != "" {
+			return d, false
+		}
+		n := maxExponent
+		if exp = strings.TrimLeft(exp, "0"); len(exp) <= 10 {
+			n, _ = strconv.Atoi(exp)
+			n = min(n, maxExponent)
+		}
+		e = esign * n
+	}
+
+	digits := intPart + frac
+	lead := len(digits) - len(strings.TrimLeft(digits, "0"))
+	digits = strings.TrimRight(digits[lead:], "0")
+	if digits == "" {
+		return decimal{}, true
+	}
+	d.digits = digits
+	d.exp = len(intPart) - lead + e
+	return d, true
+}
+
+func (d decimal) String() string {
+	if d.digits == "" {
+		return "0"
+	}
+	var sb strings.Builder
+	if d.neg {
+		sb.WriteByte('-')
+	}
+	// plain notation for the range JavaScript prints that way, 1e-6 up
+	// to 1e21, exponent notation outside it
+	sci := d.exp - 1
+	switch {
+	case sci < -6 || sci >= 21:
+		sb.WriteString(d.digits[:1])
+		if len(d.digits) > 1 {
+			sb.WriteString(".")
+			sb.WriteString(d.digits[1:])
+		}
+		sb.WriteString("e")
+		if sci > 0 {
+			sb.WriteString("+")
+		}
+		sb.WriteString(strconv.Itoa(sci))
+	case d.exp <= 0:
+		sb.WriteString("0.")
+		sb.WriteString(strings.Repeat("0", -d.exp))
+		sb.WriteString(d.digits)
+	case d.exp >= len(d.digits):
+		sb.WriteString(d.digits)
+		sb.WriteString(strings.Repeat("0", d.exp-len(d.digits)))
+	default:
+		sb.WriteString(d.digits[:d.exp])
+		sb.WriteString(".")
+		sb.WriteString(d.digits[d.exp:])
+	}
+	return sb.String()
+}
+
+// cmp orders two decimals by value
+func (d decimal) cmp(o decimal) int {
+	switch {
+	case d.digits == "" && o.digits == "":
+		return 0
+	case d.digits == "":
+		return sign(!o.neg)
+	case o.digits == "":
+		return sign(d.neg)
+	case d.neg != o.neg:
+		return sign(d.neg)
+	}
+	c := 0
+	switch {
+	case d.exp != o.exp:
+		c = sign(d.exp < o.exp)
+	default:
+		c = strings.Compare(d.digits, o.digits)
+	}
+	if d.neg {
+		return -c
+	}
+	return c
+}
+
+// sign is -1 when less is true and 1 otherwise
+func sign(less bool) int {
+	if less {
+		return -1
+	}
+	return 1
+}
+
+// toDecimal reads the number types a tree may hold
+func toDecimal(v interface{}) (decimal, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		return parseDecimal(string(n))
+	case float64:
+		return parseDecimal(strconv.FormatFloat(n, 'g', -1, 64))
+	case int:
+		return parseDecimal(strconv.Itoa(n))
+	case int64:
+		return parseDecimal(strconv.FormatInt(n, 10))
+	}
+	return decimal{}, false
+}
+
+// CompareNumbers orders two numbers by their exact value, so integers
+// beyond 2^53 that float64 cannot tell apart still compare as different.
+// ok is false when either value is not a number.
+func CompareNumbers(a, b interface{}) (c int, ok bool) {
+	x, ok := toDecimal(a)
+	if !ok {
+		return 0, false
+	}
+	y, ok := toDecimal(b)
+	if !ok {
+		return 0, false
+	}
+	return x.cmp(y), true
+}
+
+// ToFloat converts the number types a tree may hold to float64. ok is
+// false for other values and for numbers beyond the float64 range.
+func ToFloat(v interface{}) (f float64, ok bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := strconv.ParseFloat(string(n), 64)
+		return f, err == nil
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// IsInteger reports whether v is a number with no fractional part, exactly
+// rather than as a float64, so 1e400 and 2.0 are integers and 1.5 is not.
+// ok is false when v is not a number.
+func IsInteger(v interface{}) (isInt, ok bool) {
+	d, ok := toDecimal(v)
+	if !ok {
+		return false, false
+	}
+	return len(d.digits) <= d.exp, true
+}
+
+// Equal is JSON equality: numbers by exact value, so 1.0 equals 1, and
+// objects, Object or map[string]interface{}, regardless of member order.
+// Values of other types are compared by structure.
+func Equal(a, b interface{}) bool {
+	if _, ok := toDecimal(a); ok {
+		c, ok := CompareNumbers(a, b)
+		return ok && c == 0
+	}
+	if x, ok := asObject(a); ok {
+		y, ok := asObject(b)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for _, m := range x {
+			other, ok := y.Get(m.Key)
+			if !ok || !Equal(m.Value, other) {
+				return false
+			}
+		}
+		return true
+	}
+	switch x := a.(type) {
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !Equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if t := reflect.TypeOf(a); t != nil && !t.Comparable() {
+		// == panics on slices, maps and funcs
+		return reflect.DeepEqual(a, b)
+	}
+	return a == b
+}
+
+// asObject returns the members of an Object or a map[string]interface{},
+// the latter in no particular order
+func asObject(v interface{}) (Object, bool) {
+	switch o := v.(type) {
+	case Object:
+		return o, true
+	case map[string]interface{}:
+		obj := make(Object, 0, len(o))
+		for k, e := range o {
+			obj = append(obj, Member{Key: k, Value: e})
+		}
+		return obj, true
+	}
+	return nil, false
+}
+
+// NormalizeNumber rewrites a number in one canonical spelling without
+// losing precision: no leading or trailing zeros, no "+", and exponent
+// notation only outside the range 1e-6 to 1e21, so 1.50, 15E-1 and
+// 0.15e1 all become 1.5. Relaxed spellings like .5 or +1 are accepted.
+func NormalizeNumber(s string) (json.Number, bool) {
+	d, ok := parseDecimal(s)
+	if !ok {
+		return "", false
+	}
+	return json.Number(d.String()), true
+}
+
+// NormalizeNumbers rewrites every json.Number in v with NormalizeNumber,
+// changing v in place, and returns the result
+func NormalizeNumbers(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if n, ok := NormalizeNumber(string(x)); ok {
+			return n
+		}
+	case Object:
+		for i := range x {
+			x[i].Value = NormalizeNumbers(x[i].Value)
+		}
+	case []interface{}:
+		for i := range x {
+			x[i] = NormalizeNumbers(x[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,140 @@
+package tree
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompareNumbers(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		want int
+	}{
+		{json.Number("1"), json.Number("1.0"), 0},
+		{json.Number("100"), json.Number("1e2"), 0},
+		{json.Number("-0"), json.Number("0"), 0},
+		{json.Number("9007199254740993"), json.Number("9007199254740992"), 1},
+		{json.Number("1e400"), json.Number("2e400"), -1},
+		{json.Number("-2"), json.Number("-10"), 1},
+		{json.Number("0.1"), 0.1, 0},
+		{json.Number("5"), 5, 0},
+		{int64(7), json.Number("7.5"), -1},
+	}
+	for _, tt := range tests {
+		got, ok := CompareNumbers(tt.a, tt.b)
+		if !ok || got != tt.want {
+			t.Errorf("CompareNumbers(%v, %v) = %d, %v, want %d", tt.a, tt.b, got, ok, tt.want)
+		}
+	}
+	if _, ok := CompareNumbers(json.Number("1"), "1"); ok {
+		t.Errorf("a string compared as a number")
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"1.50", "1.5"},
+		{"15E-1", "1.5"},
+		{"0.15e1", "1.5"},
+		{"-0.0", "0"},
+		{"+1", "1"},
+		{".5", "0.5"},
+		{"1e21", "1e+21"},
+		{"1e20", "100000000000000000000"},
+		{"0.000001", "0.000001"},
+		{"0.0000001", "1e-7"},
+		{"12345678901234567890.000", "12345678901234567890"},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeNumber(tt.in)
+		if !ok || string(got) != tt.want {
+			t.Errorf("NormalizeNumber(%s) = %s, %v, want %s", tt.in, got, ok, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "-", "1e", "0x10", "1.2.3", "NaN"} {
+		if got, ok := NormalizeNumber(bad); ok {
+			t.Errorf("NormalizeNumber(%q) = %s, want failure", bad, got)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`1`, `1.0`, true},
+		{`9007199254740993`, `9007199254740992`, false},
+		{`"1"`, `1`, false},
+		{`null`, `null`, true},
+		{`null`, `false`, false},
+		{`{"a":1,"b":[1,2]}`, `{"b":[1,2.0],"a":1}`, true},
+		{`{"a":1}`, `{"a":1,"b":2}`, false},
+		{`{"a":null}`, `{"b":null}`, false},
+		{`[1,2]`, `[2,1]`, false},
+		{`[]`, `{}`, false},
+		{`1e400`, `10e399`, true},
+	}
+	for _, tt := range tests {
+		a, err := Decode(strings.NewReader(tt.a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Decode(strings.NewReader(tt.b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Equal(a, b); got != tt.want {
+			t.Errorf("Equal(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := Equal(b, a); got != tt.want {
+			t.Errorf("Equal(%s, %s) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestEqualMaps(t *testing.T) {
+	m := func() map[string]interface{} {
+		return map[string]interface{}{"a": json.Number("1"), "b": []interface{}{"x"}}
+	}
+	tests := []struct {
+		a, b interface{}
+		want bool
+	}{
+		{m(), m(), true},
+		{m(), Object{{Key: "b", Value: []interface{}{"x"}}, {Key: "a", Value: 1.0}}, true},
+		{m(), map[string]interface{}{"a": json.Number("1")}, false},
+		{map[string]interface{}{"a": m()}, map[string]interface{}{"a": m()}, true},
+		{[]string{"a"}, []string{"a"}, true},
+		{[]string{"a"}, []string{"b"}, false},
+		{m(), []interface{}{}, false},
+	}
+	for _, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("Equal(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsInteger(t *testing.T) {
+	tests := []struct {
+		v           interface{}
+		isInt, isOK bool
+	}{
+		{json.Number("1"), true, true},
+		{json.Number("2.0"), true, true},
+		{json.Number("1e400"), true, true},
+		{json.Number("1.5"), false, true},
+		{json.Number("1e-400"), false, true},
+		{json.Number("0"), true, true},
+		{0.25, false, true},
+		{"1", false, false},
+	}
+	for _, tt := range tests {
+		isInt, ok := IsInteger(tt.v)
+		if isInt != tt.isInt || ok != tt.isOK {
+			t.Errorf("IsInteger(%v) = %v, %v, want %v, %v", tt.v, isInt, ok, tt.isInt, tt.isOK)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package tree
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// Pointer builds an RFC 6901 JSON Pointer from reference tokens
+func Pointer(tokens ...string) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(pointerEscaper.Replace(t))
+	}
+	return sb.String()
+}
+
+// ParsePointer splits an RFC 6901 JSON Pointer into its reference tokens
+func ParsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("json pointer %q must start with '/'", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = pointerUnescaper.Replace(t)
+	}
+	return tokens, nil
+}
+
+// Resolve returns the value p points at in doc
+func Resolve(doc interface{}, p string) (interface{}, error) {
+	tokens, err := ParsePointer(p)
+	if err != nil {
+		return nil, err
+	}
+	v := doc
+	for i, t := range tokens {
+		switch c := v.(type) {
+		case Object:
+			val, ok := c.Get(t)
+			if !ok {
+				return nil, fmt.Errorf("json pointer %q: no member %q", p, t)
+			}
+			v = val
+		case map[string]interface{}:
+			val, ok := c[t]
+			if !ok {
+				return nil, fmt.Errorf("json pointer %q: no member %q", p, t)
+			}
+			v = val
+		case []interface{}:
+			idx, err := ArrayIndex(t, len(c))
+			if err != nil || idx == len(c) {
+				return nil, fmt.Errorf("json pointer %q: bad array index %q", p, t)
+			}
+			v = c[idx]
+		default:
+			return nil, fmt.Errorf("json pointer %q: %s is not a container", p, Pointer(tokens[:i]...))
+		}
+	}
+	return v, nil
+}
+
+// ArrayIndex parses a pointer token used on an array of length n. "-",
+// the position after the last element, is returned as n.
+func ArrayIndex(t string, n int) (int, error) {
+	if t == "-" {
+		return n, nil
+	}
+	if t == "" || (len(t) > 1 && t[0] == '0') || strings.Trim(t, "0123456789") != "" {
+		return 0, fmt.Errorf("bad array index %q", t)
+	}
+	i, err := strconv.Atoi(t)
+	if err != nil || i > n {
+		return 0, fmt.Errorf("array index %q out of range", t)
+	}
+	return i, nil
+}
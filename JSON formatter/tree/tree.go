@@ -0,0 +1,36 @@
+// Package tree holds the order-preserving value tree the viewer renders.
+//
+// A decoded document is made of nil, bool, json.Number, string,
+// []interface{} and Object values. Numbers keep the text they were
+// written with, so large integers and decimals print exactly as in the
+// source. Object keeps its members in the order they appear in the source
+// so output built from the tree matches the file.
+package tree
+
+// Member is one key/value pair of a JSON object
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// Object is a JSON object whose members stay in document order
+type Object []Member
+
+// Get returns the value stored under key
+func (o Object) Get(key string) (interface{}, bool) {
+	for _, m := range o {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Keys returns the member names in order
+func (o Object) Keys() []string {
+	keys := make([]string, len(o))
+	for i, m := range o {
+		keys[i] = m.Key
+	}
+	return keys
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// escapeKeys names the escape sequences terminals send for special keys
+var escapeKeys = map[string]string{
+	"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+	"\x1bOA": "up", "\x1bOB": "down", "\x1bOC": "right", "\x1bOD": "left",
+	"\x1b[H": "home", "\x1b[F": "end", "\x1bOH": "home", "\x1bOF": "end",
+	"\x1b[1~": "home", "\x1b[4~": "end", "\x1b[7~": "home", "\x1b[8~": "end",
+	"\x1b[5~": "pgup", "\x1b[6~": "pgdn",
+}
+
+// browseTerminal runs b full screen on the terminal until it quits, with
+// keys read from in and the screen drawn on out
+func browseTerminal(in, out *os.File, b *browser) error {
+	fd := int(in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	w := bufio.NewWriter(out)
+	w.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		w.WriteString("\x1b[?25h\x1b[?1049l")
+		w.Flush()
+	}()
+
+	// reads block, so they happen aside and the loop also wakes up now
+	// and then to notice the terminal being resized
+	input := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			buf := make([]byte, 256)
+			n, err := in.Read(buf)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			input <- buf[:n]
+		}
+	}()
+	tick := time.NewTicker(250 * time.Millisecond)
+	defer tick.Stop()
+
+	width, height := 0, 0
+	redraw := true
+	for !b.quit {
+		wd, ht, err := term.GetSize(int(out.Fd()))
+		if err != nil || wd <= 0 || ht < 2 {
+			wd, ht = 80, 24
+		}
+		if redraw || wd != width || ht != height {
+			width, height = wd, ht
+			b.render(w, width, height)
+			if b.clip != "" {
+				// OSC 52 hands the text to the terminal's clipboard, which
+				// also works over ssh
+				fmt.Fprintf(w, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(b.clip)))
+				b.clip = ""
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+
+		redraw = false
+		select {
+		case data := <-input:
+			for _, k := range parseKeys(data) {
+				b.handle(k)
+				if b.quit {
+					break
+				}
+			}
+			redraw = true
+		case err := <-readErr:
+			return err
+		case <-tick.C:
+		}
+	}
+	return nil
+}
+
+// parseKeys splits what one read returned into key names: special keys
+// like "up", "enter" or "ctrl-c" and otherwise the typed characters
+func parseKeys(data []byte) []string {
+	var keys []string
+	for len(data) > 0 {
+		c := data[0]
+		switch {
+		case c == 0x1b:
+			name, n := parseEscape(data)
+			if name != "" {
+				keys = append(keys, name)
+			}
+			data = data[n:]
+			continue
+		case c == '\r' || c == '\n':
+			keys = append(keys, "enter")
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, "backspace")
+		case c == '\t':
+			keys = append(keys, "tab")
+		case c >= 1 && c <= 26:
+			keys = append(keys, "ctrl-"+string(rune('a'-1+c)))
+		case c < 0x20:
+		default:
+			r, n := utf8.DecodeRune(data)
+			keys = append(keys, string(r))
+			data = data[n:]
+			continue
+		}
+		data = data[1:]
+	}
+	return keys
+}
+
+// parseEscape names the escape sequence data starts with and returns how
+// many bytes it takes; unknown sequences are skipped without a name
+func parseEscape(data []byte) (string, int) {
+	if len(data) == 1 {
+		return "esc", 1
+	}
+	for seq, name := range escapeKeys {
+		if bytes.HasPrefix(data, []byte(seq)) {
+			return name, len(seq)
+		}
+	}
+	if data[1] != '[' {
+		return "esc", 1
+	}
+	// a CSI sequence ends with a byte in @ to ~
+	for i := 2; i < len(data); i++ {
+		if data[i] >= 0x40 && data[i] <= 0x7e {
+			return "", i + 1
+		}
+	}
+	return "", len(data)
+}
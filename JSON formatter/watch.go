@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"example.com/json-view-formatter/decode"
+	"example.com/json-view-formatter/diff"
+	"example.com/json-view-formatter/tree"
+)
+
+// watchInterval is how often watched files are checked for changes.
+// Polling the modification time and size needs no platform support and
+// copes with editors that save by replacing the file.
+const watchInterval = 500 * time.Millisecond
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\x1b[H\x1b[2J"
+
+// watched is one file followed by -watch
+type watched struct {
+	path   string
+	name   string
+	format decode.Format
+
+	// modTime and size identify the version last read
+	modTime time.Time
+	size    int64
+
+	// doc is the last valid version, shown until a save fixes an error
+	doc   interface{}
+	valid bool
+	// changes marks the paths the last valid save added or changed,
+	// removed lists what it removed
+	changes map[string]diff.Kind
+	removed []diff.Change
+	updated time.Time
+	// msgs holds the errors and notes of the last read, failed tells
+	// whether it found the file invalid
+	msgs   string
+	failed bool
+}
+
+// watchFiles shows the files like the viewer does and shows them again
+// whenever one is saved, marking what changed since the previous valid
+// version. It only returns when the output cannot be written.
+func watchFiles(stdout, stderr io.Writer, paths []string, opts *options) int {
+	var files []*watched
+	for _, path := range paths {
+		format := opts.input
+		if format == "" {
+			format = decode.Detect(path)
+		}
+		files = append(files, &watched{path: path, name: displayName(path), format: format})
+	}
+	clear := isTerminal(stdout)
+	for {
+		changed := false
+		for _, f := range files {
+			if f.poll(opts) {
+				changed = true
+			}
+		}
+		if changed {
+			var buf bytes.Buffer
+			if clear {
+				buf.WriteString(clearScreen)
+			}
+			for _, f := range files {
+				f.render(&buf, opts.theme)
+			}
+			if _, err := stdout.Write(buf.Bytes()); err != nil {
+				return reportError(stderr, "watch", &writeError{err})
+			}
+		}
+		time.Sleep(watchInterval)
+	}
+}
+
+// poll reads the file again if it changed and reports whether there is
+// anything new to show
+func (f *watched) poll(opts *options) bool {
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		msg := err.Error() + "\n"
+		changed := msg != f.msgs
+		f.msgs, f.failed, f.modTime, f.size = msg, true, time.Time{}, -1
+		return changed
+	}
+	if fi.ModTime().Equal(f.modTime) && fi.Size() == f.size {
+		return false
+	}
+	f.modTime, f.size = fi.ModTime(), fi.Size()
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		f.msgs, f.failed = err.Error()+"\n", true
+		return true
+	}
+
+	var msgs bytes.Buffer
+	doc, code := decodeData(&msgs, f.name, f.format, data, opts.allErrors)
+	if code == exitOK && opts.normalize {
+		doc = tree.NormalizeNumbers(doc)
+	}
+	if code == exitOK && opts.schema != nil {
+		violations := opts.schema.Validate(doc)
+		for _, v := range violations {
+			fmt.Fprintf(&msgs, "%s: %s\n", f.name, v)
+		}
+		if len(violations) > 0 {
+			fmt.Fprintf(&msgs, "%s: %d schema violations\n", f.name, len(violations))
+			code = exitSchema
+		}
+	}
+	if code == exitOK {
+		doc, code = applyOptions(&msgs, f.name, opts, doc)
+	}
+	f.msgs, f.failed = msgs.String(), code != exitOK
+	if f.failed {
+		return true
+	}
+
+	f.changes, f.removed = nil, nil
+	if f.valid {
+		f.changes = map[string]diff.Kind{}
+		for _, c := range diff.Compare(f.doc, doc, diff.Options{}).Changes {
+			if c.Kind == diff.Removed {
+				f.removed = append(f.removed, c)
+			} else {
+				f.changes[c.Path] = c.Kind
+			}
+		}
+	}
+	f.doc, f.valid, f.updated = doc, true, time.Now()
+	return true
+}
+
+// render writes the file's section: the header, the errors of the last
+// save and the last valid tree with its changes marked in the gutter
+func (f *watched) render(w io.Writer, th *theme) {
+	p := &printer{errWriter: errWriter{w: w}, theme: th}
+	p.printf("=== %s ===", f.name)
+	if f.valid {
+		p.printf(" updated %s", f.updated.Format("15:04:05"))
+		if f.changes != nil {
+			p.printf(", %d changes", len(f.changes)+len(f.removed))
+		}
+	}
+	p.printf("\n%s", f.msgs)
+	if !f.valid {
+		return
+	}
+	if f.failed {
+		p.printf("showing the last valid version:\n")
+	}
+	for _, c := range f.removed {
+		p.printf("%s\n", formatChange(c, th != nil))
+	}
+	p.gutter = func(path, indent string) string {
+		kind := f.kindAt(path)
+		if kind == "" {
+			return indent
+		}
+		marker := diffMarkers[kind]
+		if th != nil {
+			marker = "\x1b[" + diffColors[kind] + "m" + marker + "\x1b[0m"
+		}
+		return marker + indent[1:]
+	}
+	printValue(p, f.doc, "  ", "$")
+}
+
+// kindAt returns how the last save changed the value at path: its own
+// change, or that of the added or replaced subtree it belongs to, the
+// innermost one winning
+func (f *watched) kindAt(path string) diff.Kind {
+	if k, ok := f.changes[path]; ok {
+		return k
+	}
+	var kind diff.Kind
+	longest := 0
+	for c, k := range f.changes {
+		if len(c) > longest && strings.HasPrefix(path, c) && strings.IndexByte(".[", path[len(c)]) >= 0 {
+			kind, longest = k, len(c)
+		}
+	}
+	return kind
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"example.com/json-view-formatter/decode"
+)
+
+// the watch view is the tree view with the changes of the last save in
+// the first column
+func TestWatchRender(t *testing.T) {
+	dir := writeFiles(t, "doc.json", `{"a":1,"b":{"c":[1,2]},"d":"x","e":[],"old":true}`)
+	path := filepath.Join(dir, "doc.json")
+	f := &watched{path: path, name: path, format: decode.JSON}
+	opts := &options{}
+	if !f.poll(opts) {
+		t.Fatal("the first poll found nothing")
+	}
+	// a different size makes the save visible whatever the clock says
+	if err := os.WriteFile(path, []byte(`{"a":2,"b":{"c":[1,2,{"x":{}}]},"d":"x","e":[],"new":{"y":[1]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !f.poll(opts) {
+		t.Fatal("the save went unnoticed")
+	}
+
+	var sb strings.Builder
+	f.render(&sb, nil)
+	got := sb.String()
+	got = got[strings.Index(got, "\n")+1:] // the header holds the time
+	want := `- $.old: true
+~ a: 2
+  b:
+    c:
+      [0]: 1
+      [1]: 2
++     [2]:
++       x: {}
+  d: x
+  e: []
++ new:
++   y:
++     [0]: 1
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// without changes it is the plain tree view
+	f.changes, f.removed = nil, nil
+	sb.Reset()
+	f.render(&sb, nil)
+	var plain strings.Builder
+	if err := prettyPrint(&plain, f.doc, "  ", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := sb.String(); !strings.HasSuffix(got, "\n"+plain.String()) {
+		t.Errorf("got\n%s\nwant the tree view\n%s", got, plain.String())
+	}
+}